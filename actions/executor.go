@@ -5,37 +5,82 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+	"slices"
 
 	"github.com/jbensmann/mouseless/config"
 	"github.com/jbensmann/mouseless/handlers"
 	"github.com/jbensmann/mouseless/keyboard"
-	"github.com/jbensmann/mouseless/virtual"
 	log "github.com/sirupsen/logrus"
 )
 
+// Keyboard is the virtual keyboard that the executor presses keys on.
+type Keyboard interface {
+	PressKeys(triggeredByKey uint16, codes []uint16)
+	PressKeyManually(code uint16)
+	ReleaseKeyManually(code uint16)
+	HoldKeys(codes []uint16)
+	ReleaseHeldKeys(codes []uint16)
+	OriginalKeyUp(code uint16)
+}
+
+// Mouse is the virtual mouse that the executor moves and clicks.
+type Mouse interface {
+	SetParameters(source any, params config.MouseParameters)
+	ReplaceSource(oldSource any, newSource any)
+	ChangeMoveSpeed(source any, triggeredByKey uint16, x float64, y float64)
+	ChangeScrollSpeed(source any, triggeredByKey uint16, x float64, y float64)
+	AddSpeedFactor(source any, triggeredByKey uint16, speedFactor float64)
+	ButtonPress(triggeredByKey uint16, button config.MouseButton)
+	ButtonRelease(triggeredByKey uint16)
+	OriginalKeyUp(code uint16)
+}
+
 type ExecutedBinding struct {
 	cause   *keyboard.Event
 	binding config.Binding
 }
 
+// layerStackEntry is a layer that has been activated on top of the layer stack.
+type layerStackEntry struct {
+	layer *config.Layer
+	// the key that toggled the layer, only set if isToggled is true
+	toggleKey uint16
+	isToggled bool
+	// whether the layer has been activated by a one-shot-layer binding
+	isOneShot bool
+}
+
 type Executor struct {
 	config              *config.Config
-	virtualKeyboard     *virtual.Keyboard
-	virtualMouse        *virtual.Mouse
+	virtualKeyboard     Keyboard
+	virtualMouse        Mouse
 	reloadConfigChannel chan<- struct{}
 
+	// the layer at the bottom of the layer stack at start
+	baseLayer    *config.Layer
 	currentLayer *config.Layer
-	// remember all keys that toggled a layer, and from which layer they came from
-	toggleLayerKeys     []uint16
-	toggleLayerPrevious []*config.Layer
+	// all active layers, the last one is the current layer
+	layerStack []layerStackEntry
+	// the layer stack before the last layer change (excluding toggled layers), used by layer-back
+	previousLayerStack []layerStackEntry
 	// remember all ExecPressReleaseBindings that have been executed
 	execPressReleaseBindings map[uint16]config.ExecPressReleaseBinding
+	// the macro that is currently played, if any
+	macro *macroPlayer
+	// the macro that has been started by the binding that is currently executed, if any
+	startedMacro *macroPlayer
+	// the last executed binding that can be repeated with a RepeatBinding, without layer changes and the like
+	lastBinding config.Binding
+	// closed when the commands of a repetition that run in the background are done, by the key that caused them
+	repeatedCommands map[uint16]chan struct{}
 }
 
+// NewExecutor creates an executor for the given config, which starts with the given base layer.
 func NewExecutor(
 	conf *config.Config,
-	virtualKeyboard *virtual.Keyboard,
-	virtualMouse *virtual.Mouse,
+	baseLayer *config.Layer,
+	virtualKeyboard Keyboard,
+	virtualMouse Mouse,
 	reloadConfigChannel chan struct{},
 ) *Executor {
 	b := Executor{
@@ -43,9 +88,13 @@ func NewExecutor(
 		virtualKeyboard:          virtualKeyboard,
 		virtualMouse:             virtualMouse,
 		reloadConfigChannel:      reloadConfigChannel,
-		currentLayer:             conf.Layers[0],
+		baseLayer:                baseLayer,
+		currentLayer:             baseLayer,
+		layerStack:               []layerStackEntry{{layer: baseLayer}},
 		execPressReleaseBindings: make(map[uint16]config.ExecPressReleaseBinding),
+		repeatedCommands:         make(map[uint16]chan struct{}),
 	}
+	virtualMouse.SetParameters(&b, baseLayer.MouseParameters)
 	return &b
 }
 
@@ -66,19 +115,28 @@ func (b *Executor) HandleEvent(eventBinding handlers.EventBinding) {
 }
 
 func (b *Executor) ExecuteBinding(binding config.Binding, causeCode uint16) {
+	if repeatable := config.RepeatableBinding(binding, causeCode); repeatable != nil {
+		b.lastBinding = repeatable
+	}
+	b.startedMacro = nil
+	b.executeBinding(binding, causeCode)
+	b.startedMacro = nil
+}
+
+func (b *Executor) executeBinding(binding config.Binding, causeCode uint16) {
 	log.Debugf("Executing %T: %+v", binding, binding)
 
 	switch t := binding.(type) {
 	case config.MultiBinding:
 		for _, binding := range t.Bindings {
-			b.ExecuteBinding(binding, causeCode)
+			b.executeBinding(binding, causeCode)
 		}
 	case config.SpeedBinding:
-		b.virtualMouse.AddSpeedFactor(causeCode, t.Speed)
+		b.virtualMouse.AddSpeedFactor(b, causeCode, t.Speed)
 	case config.ScrollBinding:
-		b.virtualMouse.ChangeScrollSpeed(causeCode, t.X, t.Y)
+		b.virtualMouse.ChangeScrollSpeed(b, causeCode, t.X, t.Y)
 	case config.MoveBinding:
-		b.virtualMouse.ChangeMoveSpeed(causeCode, t.X, t.Y)
+		b.virtualMouse.ChangeMoveSpeed(b, causeCode, t.X, t.Y)
 	case config.ButtonBinding:
 		b.virtualMouse.ButtonPress(causeCode, t.Button)
 	case config.KeyBinding:
@@ -95,26 +153,46 @@ func (b *Executor) ExecuteBinding(binding config.Binding, causeCode uint16) {
 		b.virtualKeyboard.PressKeyManually(t.Key)
 	case config.KeyReleaseBinding:
 		b.virtualKeyboard.ReleaseKeyManually(t.Key)
+	case config.OneShotBinding:
+		b.virtualKeyboard.HoldKeys(t.Keys)
+	case config.OneShotLayerBinding:
+		if layer, ok := b.GetLayer(t.Layer); ok {
+			b.setLayerStack(append(b.layerStack, layerStackEntry{layer: layer, isOneShot: true}))
+		}
+	case config.OneShotReleaseBinding:
+		// the modifiers apply to all keys of a macro that has been started together with this binding
+		if b.startedMacro == nil || !b.startedMacro.releaseAfter(t.Keys) {
+			b.virtualKeyboard.ReleaseHeldKeys(t.Keys)
+		}
+		for _, name := range t.Layers {
+			b.removeOneShotLayer(name)
+		}
 	case config.LayerBinding:
-		// deactivate any toggled layers
-		if b.toggleLayerPrevious != nil {
-			b.toggleLayerKeys = nil
-			b.toggleLayerPrevious = nil
-		}
-		for _, layer := range b.config.Layers {
-			if layer.Name == t.Layer {
-				b.goToLayer(layer)
-				break
-			}
+		// deactivate all other layers, including toggled ones
+		if layer, ok := b.GetLayer(t.Layer); ok {
+			b.rememberLayerStack()
+			b.setLayerStack([]layerStackEntry{{layer: layer}})
 		}
 	case config.ToggleLayerBinding:
-		for _, layer := range b.config.Layers {
-			if layer.Name == t.Layer {
-				b.toggleLayerKeys = append(b.toggleLayerKeys, causeCode)
-				b.toggleLayerPrevious = append(b.toggleLayerPrevious, b.currentLayer)
-				b.goToLayer(layer)
-				break
-			}
+		if layer, ok := b.GetLayer(t.Layer); ok {
+			b.setLayerStack(append(b.layerStack, layerStackEntry{layer: layer, toggleKey: causeCode, isToggled: true}))
+		}
+	case config.PushLayerBinding:
+		if layer, ok := b.GetLayer(t.Layer); ok {
+			b.rememberLayerStack()
+			b.setLayerStack(append(b.layerStack, layerStackEntry{layer: layer}))
+		}
+	case config.PopLayerBinding:
+		// the bottom layer is never removed
+		if len(b.layerStack) > 1 {
+			b.rememberLayerStack()
+			b.setLayerStack(b.layerStack[:len(b.layerStack)-1])
+		}
+	case config.LayerBackBinding:
+		if b.previousLayerStack != nil {
+			previous := b.previousLayerStack
+			b.rememberLayerStack()
+			b.setLayerStack(previous)
 		}
 	case config.ReloadConfigBinding:
 		select {
@@ -128,15 +206,167 @@ func (b *Executor) ExecuteBinding(binding config.Binding, causeCode uint16) {
 		log.Debugf("Executing: %s", t.PressCommand)
 		executeCommandWithKey(t.PressCommand, causeCode)
 		b.execPressReleaseBindings[causeCode] = t
+	case config.MacroBinding:
+		b.stopMacro()
+		b.macro = startMacro(b.virtualKeyboard, t.Steps)
+		b.startedMacro = b.macro
+	case config.TypeBinding:
+		b.stopMacro()
+		b.macro = startMacro(b.virtualKeyboard, t.Steps)
+		b.startedMacro = b.macro
+	case config.StopMacroBinding:
+		b.stopMacro()
+	case config.RepeatBinding:
+		b.repeat(t.Count, causeCode)
+	}
+}
+
+// repeat executes the last repeatable binding count times. All but the last repetition are released right away, the
+// last one is released together with the given key. Only the outputs of the repetitions are released, not the actions
+// of the key itself like the release command of an exec-press-release.
+func (b *Executor) repeat(count int, causeCode uint16) {
+	if b.lastBinding == nil {
+		log.Debugf("Nothing to repeat")
+		return
+	}
+	// macros would cancel each other and commands would block, so both are collected and run one after another
+	var steps []config.MacroStep
+	var commands []string
+	for _, binding := range flattenBinding(b.lastBinding) {
+		switch t := binding.(type) {
+		case config.MacroBinding:
+			steps = append(steps, repeatSteps(t.Steps, count)...)
+		case config.TypeBinding:
+			steps = append(steps, repeatSteps(t.Steps, count)...)
+		case config.ExecBinding:
+			for i := 0; i < count; i++ {
+				commands = append(commands, t.Command)
+			}
+		case config.ExecPressReleaseBinding:
+			for i := 1; i < count; i++ {
+				commands = append(commands, t.PressCommand, t.ReleaseCommand)
+			}
+			commands = append(commands, t.PressCommand)
+			b.execPressReleaseBindings[causeCode] = t
+		case config.MoveBinding:
+			// a move keeps going until the key is released, so it is sped up instead
+			b.executeBinding(config.MoveBinding{X: t.X * float64(count), Y: t.Y * float64(count)}, causeCode)
+		case config.ScrollBinding:
+			b.executeBinding(config.ScrollBinding{X: t.X * float64(count), Y: t.Y * float64(count)}, causeCode)
+		case config.KeyBinding:
+			for i := 1; i < count; i++ {
+				for _, key := range t.KeyCombo {
+					b.virtualKeyboard.PressKeyManually(key)
+				}
+				for j := len(t.KeyCombo) - 1; j >= 0; j-- {
+					b.virtualKeyboard.ReleaseKeyManually(t.KeyCombo[j])
+				}
+			}
+			b.executeBinding(t, causeCode)
+		case config.ButtonBinding:
+			for i := 1; i < count; i++ {
+				b.virtualMouse.ButtonPress(causeCode, t.Button)
+				b.virtualMouse.ButtonRelease(causeCode)
+			}
+			b.executeBinding(t, causeCode)
+		}
+	}
+	if len(steps) > 0 {
+		b.executeBinding(config.MacroBinding{Steps: steps}, causeCode)
+	}
+	if len(commands) > 0 {
+		done := make(chan struct{})
+		b.repeatedCommands[causeCode] = done
+		go func() {
+			defer close(done)
+			for _, command := range commands {
+				executeCommandWithKey(command, causeCode)
+			}
+		}()
+	}
+}
+
+// flattenBinding returns the bindings of nested multi bindings, or the binding itself if it is not a multi binding.
+func flattenBinding(binding config.Binding) []config.Binding {
+	multi, ok := binding.(config.MultiBinding)
+	if !ok {
+		return []config.Binding{binding}
+	}
+	var bindings []config.Binding
+	for _, binding := range multi.Bindings {
+		bindings = append(bindings, flattenBinding(binding)...)
 	}
+	return bindings
+}
+
+// repeatSteps returns the given macro steps count times one after another.
+func repeatSteps(steps []config.MacroStep, count int) []config.MacroStep {
+	var repeated []config.MacroStep
+	for i := 0; i < count; i++ {
+		repeated = append(repeated, steps...)
+	}
+	return repeated
+}
+
+// TakeOverState takes over the runtime state of an executor of a previous config, i.e. the active layers, which are
+// matched by name, and the release commands of exec-press-release bindings whose keys are still pressed.
+func (b *Executor) TakeOverState(previous *Executor) {
+	previous.stopMacro()
+	b.virtualMouse.ReplaceSource(previous, b)
+	stack := b.matchLayerStack(previous.layerStack)
+	// the bottom layer must never be removed, so fall back to the base layer if it does not exist anymore
+	if len(stack) == 0 || stack[0].layer.Name != previous.layerStack[0].layer.Name {
+		stack = append([]layerStackEntry{{layer: b.baseLayer}}, stack...)
+	}
+	b.layerStack = stack
+	b.currentLayer = stack[len(stack)-1].layer
+	b.virtualMouse.SetParameters(b, b.currentLayer.MouseParameters)
+	if previous.currentLayer.Name != b.currentLayer.Name {
+		log.Infof("Layer %s does not exist anymore, switching to layer %s", previous.currentLayer.Name,
+			b.currentLayer.Name)
+	}
+	if previous.previousLayerStack != nil {
+		b.previousLayerStack = b.matchLayerStack(previous.previousLayerStack)
+		if len(b.previousLayerStack) == 0 {
+			b.previousLayerStack = nil
+		}
+	}
+	for code, binding := range previous.execPressReleaseBindings {
+		b.execPressReleaseBindings[code] = binding
+	}
+	for code, done := range previous.repeatedCommands {
+		b.repeatedCommands[code] = done
+	}
+	b.lastBinding = previous.lastBinding
+}
+
+// matchLayerStack returns the given layer stack with the layers of the current config that have the same names,
+// layers that do not exist anymore are omitted.
+func (b *Executor) matchLayerStack(stack []layerStackEntry) []layerStackEntry {
+	var matched []layerStackEntry
+	for _, entry := range stack {
+		if layer, ok := b.GetLayer(entry.layer.Name); ok {
+			entry.layer = layer
+			matched = append(matched, entry)
+		}
+	}
+	return matched
 }
 
 func (b *Executor) CurrentLayer() *config.Layer {
 	return b.currentLayer
 }
 
+func (b *Executor) LayerStack() []*config.Layer {
+	layers := make([]*config.Layer, len(b.layerStack))
+	for i, entry := range b.layerStack {
+		layers[i] = entry.layer
+	}
+	return layers
+}
+
 func (b *Executor) BaseLayer() *config.Layer {
-	return b.config.Layers[0]
+	return b.baseLayer
 }
 
 func (b *Executor) GetLayer(name string) (*config.Layer, bool) {
@@ -150,39 +380,91 @@ func (b *Executor) GetLayer(name string) (*config.Layer, bool) {
 
 func (b *Executor) KeyReleased(code uint16) {
 	// go back to the previous layer when toggleLayerKey is released
-	for i, key := range b.toggleLayerKeys {
-		if key == code {
-			b.goToLayer(b.toggleLayerPrevious[i])
-			// all layers that have been toggled after the current one are removed as well
-			b.toggleLayerKeys = b.toggleLayerKeys[:i]
-			b.toggleLayerPrevious = b.toggleLayerPrevious[:i]
+	for i, entry := range b.layerStack {
+		if entry.isToggled && entry.toggleKey == code {
+			// all layers that have been activated after the toggled one are removed as well
+			b.setLayerStack(b.layerStack[:i])
 			break
 		}
 	}
 
 	// execute ExecPressReleaseBindings
 	if binding, ok := b.execPressReleaseBindings[code]; ok {
-		executeCommandWithKey(binding.ReleaseCommand, code)
+		if done, ok := b.repeatedCommands[code]; ok {
+			// the release command must follow the commands of the repetition
+			go func() {
+				<-done
+				executeCommandWithKey(binding.ReleaseCommand, code)
+			}()
+		} else {
+			executeCommandWithKey(binding.ReleaseCommand, code)
+		}
 		delete(b.execPressReleaseBindings, code)
 	}
+	delete(b.repeatedCommands, code)
 
 	// inform the keyboard and mouse about key releases
 	b.virtualKeyboard.OriginalKeyUp(code)
 	b.virtualMouse.OriginalKeyUp(code)
 }
 
-// goToLayer switches to the given layer and executes the appropriate exit and enter commands if set.
+// setLayerStack replaces the layer stack and switches to the layer on top of it.
+func (b *Executor) setLayerStack(stack []layerStackEntry) {
+	b.layerStack = slices.Clip(stack)
+	b.goToLayer(stack[len(stack)-1].layer)
+}
+
+// removeOneShotLayer removes the topmost layer with the given name that has been activated by a one-shot-layer binding,
+// the layers above it stay active. Nothing happens if it has already been removed, e.g. by a layer binding.
+func (b *Executor) removeOneShotLayer(name string) {
+	for i := len(b.layerStack) - 1; i > 0; i-- {
+		if entry := b.layerStack[i]; entry.isOneShot && entry.layer.Name == name {
+			stack := slices.Delete(slices.Clone(b.layerStack), i, i+1)
+			if i == len(b.layerStack)-1 {
+				b.setLayerStack(stack)
+			} else {
+				// the current layer does not change
+				b.layerStack = stack
+			}
+			return
+		}
+	}
+}
+
+// rememberLayerStack stores the current layer stack without toggled and one-shot layers, so that layer-back can return to it.
+func (b *Executor) rememberLayerStack() {
+	b.previousLayerStack = slices.DeleteFunc(slices.Clone(b.layerStack), func(entry layerStackEntry) bool {
+		return entry.isToggled || entry.isOneShot
+	})
+}
+
+// goToLayer switches to the given layer and its mouse parameters, and executes the appropriate exit and enter commands
+// if set.
 func (b *Executor) goToLayer(layer *config.Layer) {
+	// a layer change cancels the macro, unless it has been started by the binding that changes the layer, e.g. the
+	// key of a one-shot layer
+	if b.macro != b.startedMacro {
+		b.stopMacro()
+	}
 	if b.currentLayer.ExitCommand != nil {
 		executeCommand(*b.currentLayer.ExitCommand)
 	}
 	log.Debugf("Switching to layer %v", layer.Name)
 	b.currentLayer = layer
+	b.virtualMouse.SetParameters(b, layer.MouseParameters)
 	if layer.EnterCommand != nil {
 		executeCommand(*layer.EnterCommand)
 	}
 }
 
+// stopMacro cancels the macro that is currently played, if any.
+func (b *Executor) stopMacro() {
+	if b.macro != nil {
+		b.macro.stop()
+		b.macro = nil
+	}
+}
+
 // executeCommandWithKey executes the given command with the given key as environment variable.
 func executeCommandWithKey(command string, causeCode uint16) {
 	alias, exists := config.GetKeyAlias(causeCode)
@@ -0,0 +1,389 @@
+package actions
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jbensmann/mouseless/config"
+	"github.com/jbensmann/mouseless/handlers"
+	"github.com/jbensmann/mouseless/keyboard"
+)
+
+// keyboardMock records the key presses and releases, e.g. "Pa Ra".
+type keyboardMock struct {
+	mutex  sync.Mutex
+	events []string
+}
+
+func (k *keyboardMock) record(prefix string, codes ...uint16) {
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+	for _, code := range codes {
+		alias, _ := config.GetKeyAlias(code)
+		k.events = append(k.events, prefix+alias)
+	}
+}
+
+func (k *keyboardMock) String() string {
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+	return strings.Join(k.events, " ")
+}
+
+func (k *keyboardMock) PressKeys(_ uint16, codes []uint16) { k.record("P", codes...) }
+func (k *keyboardMock) PressKeyManually(code uint16)       { k.record("P", code) }
+func (k *keyboardMock) ReleaseKeyManually(code uint16)     { k.record("R", code) }
+func (k *keyboardMock) HoldKeys(codes []uint16)            { k.record("P", codes...) }
+func (k *keyboardMock) ReleaseHeldKeys(codes []uint16)     { k.record("R", codes...) }
+func (k *keyboardMock) OriginalKeyUp(_ uint16)             {}
+
+// mouseMock records the move and scroll speeds, e.g. "M1,0 S0,-1".
+type mouseMock struct {
+	mutex  sync.Mutex
+	events []string
+}
+
+func (m *mouseMock) record(prefix string, x float64, y float64) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.events = append(m.events, fmt.Sprintf("%s%v,%v", prefix, x, y))
+}
+
+func (m *mouseMock) String() string {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return strings.Join(m.events, " ")
+}
+
+func (m *mouseMock) SetParameters(_ any, _ config.MouseParameters) {}
+func (m *mouseMock) ReplaceSource(_ any, _ any)                    {}
+func (m *mouseMock) ChangeMoveSpeed(_ any, _ uint16, x float64, y float64) {
+	m.record("M", x, y)
+}
+func (m *mouseMock) ChangeScrollSpeed(_ any, _ uint16, x float64, y float64) {
+	m.record("S", x, y)
+}
+func (m *mouseMock) AddSpeedFactor(_ any, _ uint16, _ float64)  {}
+func (m *mouseMock) ButtonPress(_ uint16, _ config.MouseButton) {}
+func (m *mouseMock) ButtonRelease(_ uint16)                     {}
+func (m *mouseMock) OriginalKeyUp(_ uint16)                     {}
+
+// newTestChain returns the first handler of a chain with a one-shot handler and an executor for the given config,
+// together with the executor and the keyboard mock.
+func newTestChain(t *testing.T, configStr string) (handlers.EventHandler, *Executor, *keyboardMock) {
+	t.Helper()
+	conf, err := config.ParseConfig([]byte(configStr))
+	if err != nil {
+		t.Fatal(fmt.Sprintf("Error parsing config: %v", err))
+	}
+	keyboardMock := &keyboardMock{}
+	executor := NewExecutor(conf, conf.Layers[0], keyboardMock, &mouseMock{}, make(chan struct{}, 1))
+	chain := []handlers.EventHandler{
+		handlers.NewDefaultHandler(),
+		handlers.NewOneShotHandler(0, true),
+	}
+	for i, handler := range chain {
+		handler.SetLayerManager(executor)
+		if i < len(chain)-1 {
+			handler.SetNextHandler(chain[i+1])
+		} else {
+			handler.SetNextHandler(executor)
+		}
+	}
+	return chain[0], executor, keyboardMock
+}
+
+// feedEvents passes the given events to the handler, e.g. "Pa Ra".
+func feedEvents(handler handlers.EventHandler, events string) {
+	for _, s := range strings.Fields(events) {
+		code, _ := config.GetKeyCode(s[1:])
+		event := keyboard.Event{Code: code, IsPress: s[0] == 'P', Time: time.Now()}
+		handler.HandleEvent(handlers.EventBinding{Event: event})
+	}
+}
+
+// waitForMacro waits until the macro of the executor has finished.
+func waitForMacro(t *testing.T, executor *Executor) {
+	t.Helper()
+	if executor.macro == nil {
+		t.Fatal("expected a macro to be played")
+	}
+	select {
+	case <-executor.macro.done:
+	case <-time.After(time.Second):
+		t.Fatal("the macro did not finish")
+	}
+}
+
+func TestMacroOnOneShotLayer(t *testing.T) {
+	handler, executor, keyboardMock := newTestChain(t, `
+layers:
+- name: base
+  bindings:
+    q: one-shot-layer symbols
+- name: symbols
+  bindings:
+    m: macro a; wait 20; b c
+`)
+	feedEvents(handler, "Pq Rq Pm Rm")
+	if name := executor.CurrentLayer().Name; name != "base" {
+		t.Errorf("expected the one-shot layer to be released but the current layer is %s", name)
+	}
+	waitForMacro(t, executor)
+	if events := keyboardMock.String(); events != "Pa Ra Pb Rb Pc Rc" {
+		t.Errorf("expected the macro to be played completely but got: %s", events)
+	}
+}
+
+func TestMacroOnLayerChange(t *testing.T) {
+	handler, executor, keyboardMock := newTestChain(t, `
+layers:
+- name: base
+  bindings:
+    m: {action: multi, bindings: ["macro a; wait 20; b", layer other]}
+    k: macro e; wait 20; f
+    s: stop-macro
+- name: other
+  bindings:
+    m: macro c; wait 20; d
+    n: layer base
+`)
+	// the layer change of the binding that starts the macro does not cancel it
+	feedEvents(handler, "Pm Rm")
+	waitForMacro(t, executor)
+	if events := keyboardMock.String(); events != "Pa Ra Pb Rb" {
+		t.Errorf("expected the macro to be played completely but got: %s", events)
+	}
+
+	// a later layer change cancels it
+	feedEvents(handler, "Pm Rm")
+	time.Sleep(5 * time.Millisecond)
+	feedEvents(handler, "Pn Rn")
+	if executor.macro != nil {
+		t.Errorf("expected the macro to be cancelled by the layer change")
+	}
+
+	// a new macro cancels the running one, as does stop-macro
+	feedEvents(handler, "Pk Rk")
+	time.Sleep(5 * time.Millisecond)
+	feedEvents(handler, "Pk Rk")
+	time.Sleep(5 * time.Millisecond)
+	feedEvents(handler, "Ps Rs")
+	if executor.macro != nil {
+		t.Errorf("expected the macro to be stopped")
+	}
+	time.Sleep(30 * time.Millisecond)
+	if events := keyboardMock.String(); events != "Pa Ra Pb Rb Pc Rc Pe Re Pe Re" {
+		t.Errorf("expected the macros to be cancelled but got: %s", events)
+	}
+}
+
+func TestOneShotWithMacro(t *testing.T) {
+	handler, executor, keyboardMock := newTestChain(t, `
+layers:
+- name: base
+  bindings:
+    o: one-shot leftshift
+    q: one-shot-layer symbols
+    m: macro a; wait 20; b
+    x: x
+- name: symbols
+  bindings:
+    m: macro c; wait 20; d
+`)
+	// the modifier is released after the macro, the layer right away
+	feedEvents(handler, "Po Ro Pq Rq Pm Rm")
+	time.Sleep(5 * time.Millisecond)
+	if name := executor.CurrentLayer().Name; name != "base" {
+		t.Errorf("expected the one-shot layer to be released but the current layer is %s", name)
+	}
+	if events := keyboardMock.String(); events != "Pleftshift Pc Rc" {
+		t.Errorf("expected the modifier to be held while the macro is played but got: %s", events)
+	}
+	waitForMacro(t, executor)
+	if events := keyboardMock.String(); events != "Pleftshift Pc Rc Pd Rd Rleftshift" {
+		t.Errorf("expected the modifier to be released after the macro but got: %s", events)
+	}
+
+	// other keys release the modifier right away, even while a macro is played
+	feedEvents(handler, "Pm Rm")
+	time.Sleep(5 * time.Millisecond)
+	feedEvents(handler, "Po Ro Px Rx")
+	if events := keyboardMock.String(); !strings.HasSuffix(events, "Pa Ra Pleftshift Px Rleftshift") {
+		t.Errorf("expected the modifier to be released after the key but got: %s", events)
+	}
+	waitForMacro(t, executor)
+}
+
+func TestRepeatCountMouse(t *testing.T) {
+	handler, executor, keyboardMock := newTestChain(t, `
+layers:
+- name: base
+  bindings:
+    m: move 10 0
+    s: scroll up
+    k: multi move 0 5; a
+    r: repeat-count 3
+`)
+	mouseMock := executor.virtualMouse.(*mouseMock)
+	// the speed is multiplied instead of repeating the movement
+	feedEvents(handler, "Pm Rm Pr Rr")
+	if events := mouseMock.String(); events != "M10,0 M30,0" {
+		t.Errorf("expected the move speed to be tripled but got: %s", events)
+	}
+	mouseMock.events = nil
+	feedEvents(handler, "Ps Rs Pr Rr")
+	if events := mouseMock.String(); events != "S0,-1 S0,-3" {
+		t.Errorf("expected the scroll speed to be tripled but got: %s", events)
+	}
+	mouseMock.events = nil
+	feedEvents(handler, "Pk Rk Pr Rr")
+	if events := mouseMock.String(); events != "M0,5 M0,15" {
+		t.Errorf("expected the move speed to be tripled but got: %s", events)
+	}
+	if events := keyboardMock.String(); events != "Pa Pa Ra Pa Ra Pa" {
+		t.Errorf("expected the key to be repeated but got: %s", events)
+	}
+}
+
+func TestRepeatCountKeepsLayer(t *testing.T) {
+	handler, executor, keyboardMock := newTestChain(t, `
+layers:
+- name: base
+  bindings:
+    a: a
+    r: multi toggle-layer nav; repeat-count 3
+- name: nav
+  bindings:
+    a: b
+`)
+	// the layer of the repeat key stays active while the repetitions are released
+	feedEvents(handler, "Pa Ra Pr")
+	if name := executor.CurrentLayer().Name; name != "nav" {
+		t.Errorf("expected the layer to stay active during the repetition but the current layer is %s", name)
+	}
+	feedEvents(handler, "Pa Ra Rr")
+	if events := keyboardMock.String(); events != "Pa Pa Ra Pa Ra Pa Pb" {
+		t.Errorf("expected the repeat key to keep the layer but got: %s", events)
+	}
+	if name := executor.CurrentLayer().Name; name != "base" {
+		t.Errorf("expected the layer to be released with the key but the current layer is %s", name)
+	}
+}
+
+func TestRepeatCountMacro(t *testing.T) {
+	handler, executor, keyboardMock := newTestChain(t, `
+layers:
+- name: base
+  bindings:
+    m: {action: multi, bindings: [x, macro a; b]}
+    r: repeat-count 2
+`)
+	// the repetitions of a macro in a multi are played as one macro, so that they do not cancel each other
+	feedEvents(handler, "Pm Rm")
+	waitForMacro(t, executor)
+	feedEvents(handler, "Pr Rr")
+	waitForMacro(t, executor)
+	if events := keyboardMock.String(); events != "Px Pa Ra Pb Rb Px Rx Px Pa Ra Pb Rb Pa Ra Pb Rb" {
+		t.Errorf("expected the macro to be played twice but got: %s", events)
+	}
+}
+
+func TestRepeatCountExecPressRelease(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "commands")
+	handler, _, _ := newTestChain(t, fmt.Sprintf(`
+layers:
+- name: base
+  bindings:
+    e: exec-press-release echo p >> %[1]s; echo r >> %[1]s
+    r: repeat-count 3
+`, file))
+	readCommands := func() string {
+		data, _ := os.ReadFile(file)
+		return strings.Join(strings.Fields(string(data)), " ")
+	}
+	waitForCommands := func(expected string) {
+		t.Helper()
+		for deadline := time.Now().Add(time.Second); time.Now().Before(deadline); {
+			if readCommands() == expected {
+				return
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+		t.Errorf("expected the commands %q but got: %q", expected, readCommands())
+	}
+	feedEvents(handler, "Pe Re")
+	waitForCommands("p r")
+	// the last repetition is released together with the repeat key
+	feedEvents(handler, "Pr")
+	waitForCommands("p r p r p r p")
+	feedEvents(handler, "Rr")
+	waitForCommands("p r p r p r p r")
+}
+
+// layerStackNames returns the names of the active layers of the executor from bottom to top, e.g. "base nav".
+func layerStackNames(executor *Executor) string {
+	var names []string
+	for _, layer := range executor.LayerStack() {
+		names = append(names, layer.Name)
+	}
+	return strings.Join(names, " ")
+}
+
+func TestLayerStack(t *testing.T) {
+	configStr := `
+layers:
+- name: base
+  bindings:
+    n: push-layer nav
+    s: push-layer sym
+    l: layer num
+    t: toggle-layer sym
+    o: pop-layer
+    k: layer-back
+- name: nav
+  bindings:
+    _: ~
+- name: sym
+  bindings:
+    _: ~
+- name: num
+  bindings:
+    t: toggle-layer sym
+    k: layer-back
+`
+	tests := []struct {
+		events   string
+		expected string
+	}{
+		// pushed layers are popped in reverse order, the base layer is never popped
+		{"Pn Rn Ps Rs", "base nav sym"},
+		{"Pn Rn Ps Rs Po Ro", "base nav"},
+		{"Pn Rn Ps Rs Po Ro Po Ro Po Ro", "base"},
+		// layer-back returns to the stack before the last layer, push-layer or pop-layer
+		{"Pl Rl Pk Rk", "base"},
+		{"Pl Rl Pk Rk Pk Rk", "num"},
+		{"Pn Rn Pk Rk", "base"},
+		{"Pn Rn Ps Rs Po Ro Pk Rk", "base nav sym"},
+		// toggled layers are not remembered by layer-back
+		{"Pl Rl Pt Pk Rk Rt", "base"},
+		{"Pl Rl Pt Pk Rk Rt Pk Rk", "num"},
+		{"Pt Pk Rk", "base sym"},
+		// releasing a toggle-layer key also removes the layers pushed on top of it
+		{"Pt Pn Rn", "base sym nav"},
+		{"Pt Pn Rn Rt", "base"},
+		{"Pn Rn Pt Rt", "base nav"},
+	}
+	for _, test := range tests {
+		handler, executor, _ := newTestChain(t, configStr)
+		feedEvents(handler, test.events)
+		if names := layerStackNames(executor); names != test.expected {
+			t.Errorf("expected the layers (%s) but got (%s) for the events (%s)", test.expected, names, test.events)
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package actions
+
+import (
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/jbensmann/mouseless/config"
+	log "github.com/sirupsen/logrus"
+)
+
+// macroPlayer plays the steps of a macro in its own goroutine, so that waits do not block the handling of other
+// events.
+type macroPlayer struct {
+	virtualKeyboard Keyboard
+	cancel          chan struct{}
+	done            chan struct{}
+	// the keys that have been pressed by the macro and not yet released
+	pressedKeys []uint16
+
+	// guards the fields below, which are also accessed by the executor
+	mutex sync.Mutex
+	// keys held with HoldKeys that are released when the macro has finished, e.g. one-shot modifiers
+	heldKeys []uint16
+	finished bool
+}
+
+// startMacro starts playing the given steps and returns the player, which can be used to cancel it.
+func startMacro(virtualKeyboard Keyboard, steps []config.MacroStep) *macroPlayer {
+	m := &macroPlayer{
+		virtualKeyboard: virtualKeyboard,
+		cancel:          make(chan struct{}),
+		done:            make(chan struct{}),
+	}
+	go m.play(steps)
+	return m
+}
+
+// stop cancels the macro if it is still running and waits until all keys that it pressed have been released.
+func (m *macroPlayer) stop() {
+	select {
+	case <-m.cancel:
+	default:
+		close(m.cancel)
+	}
+	<-m.done
+}
+
+// releaseAfter releases the given keys, which have been pressed with HoldKeys, once the macro has finished. It returns
+// false if the macro has already finished, then the keys have to be released by the caller.
+func (m *macroPlayer) releaseAfter(keys []uint16) bool {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if m.finished {
+		return false
+	}
+	m.heldKeys = append(m.heldKeys, keys...)
+	return true
+}
+
+func (m *macroPlayer) play(steps []config.MacroStep) {
+	defer close(m.done)
+	defer m.finish()
+	for _, step := range steps {
+		select {
+		case <-m.cancel:
+			log.Debugf("Macro cancelled")
+			return
+		default:
+		}
+		switch step.Type {
+		case config.MacroStepTap:
+			for _, code := range step.Keys {
+				m.press(code)
+			}
+			for i := len(step.Keys) - 1; i >= 0; i-- {
+				m.release(step.Keys[i])
+			}
+		case config.MacroStepPress:
+			for _, code := range step.Keys {
+				m.press(code)
+			}
+		case config.MacroStepRelease:
+			for i := len(step.Keys) - 1; i >= 0; i-- {
+				m.release(step.Keys[i])
+			}
+		case config.MacroStepWait:
+			select {
+			case <-m.cancel:
+				log.Debugf("Macro cancelled")
+				return
+			case <-time.After(time.Duration(step.WaitMs) * time.Millisecond):
+			}
+		}
+	}
+}
+
+func (m *macroPlayer) press(code uint16) {
+	m.virtualKeyboard.PressKeyManually(code)
+	if !slices.Contains(m.pressedKeys, code) {
+		m.pressedKeys = append(m.pressedKeys, code)
+	}
+}
+
+func (m *macroPlayer) release(code uint16) {
+	if i := slices.Index(m.pressedKeys, code); i >= 0 {
+		m.virtualKeyboard.ReleaseKeyManually(code)
+		m.pressedKeys = slices.Delete(m.pressedKeys, i, i+1)
+	}
+}
+
+// finish releases all keys that are still pressed by the macro and the keys passed to releaseAfter.
+func (m *macroPlayer) finish() {
+	m.releaseAll()
+	m.mutex.Lock()
+	m.finished = true
+	heldKeys := m.heldKeys
+	m.mutex.Unlock()
+	if len(heldKeys) > 0 {
+		m.virtualKeyboard.ReleaseHeldKeys(heldKeys)
+	}
+}
+
+// releaseAll releases the keys that are still pressed, in reverse order of pressing.
+func (m *macroPlayer) releaseAll() {
+	for len(m.pressedKeys) > 0 {
+		m.release(m.pressedKeys[len(m.pressedKeys)-1])
+	}
+}
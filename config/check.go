@@ -0,0 +1,259 @@
+package config
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Check validates the semantics of the given config, which are not covered by parsing it.
+// It returns all problems that have been found.
+func Check(conf *Config) []error {
+	var problems []error
+	problems = append(problems, checkLayerReferences(conf)...)
+	problems = append(problems, checkUnreachableLayers(conf)...)
+	problems = append(problems, checkShadowingCombos(conf)...)
+	problems = append(problems, checkTapHoldTimeouts(conf)...)
+	return problems
+}
+
+// checkLayerReferences checks that all layers that are referenced in bindings exist.
+func checkLayerReferences(conf *Config) []error {
+	var problems []error
+	for _, layer := range conf.Layers {
+		forEachLayerBinding(layer, func(key string, binding Binding, pos Position) {
+			for _, name := range referencedLayers(binding) {
+				if !slices.ContainsFunc(conf.Layers, func(l *Layer) bool { return l.Name == name }) {
+					problems = append(problems, errorAt(pos, "layer '%s': the binding of '%s' references the unknown layer '%s'",
+						layer.Name, key, name))
+				}
+			}
+		})
+	}
+	for _, sequence := range conf.LeaderSequences {
+		for _, name := range referencedLayers(sequence.Binding) {
+			if !slices.ContainsFunc(conf.Layers, func(l *Layer) bool { return l.Name == name }) {
+				problems = append(problems, errorAt(sequence.pos, "the leader sequence '%s' references the unknown "+
+					"layer '%s'", formatSequence(sequence.Keys), name))
+			}
+		}
+	}
+	return problems
+}
+
+// checkUnreachableLayers checks that all layers can be reached from the initial layer or the initial layer of a
+// profile. Layers that are extended by other layers are not reported, since they might only be used as a base for
+// other layers.
+func checkUnreachableLayers(conf *Config) []error {
+	initialLayers := []string{conf.Layers[0].Name}
+	for _, profile := range conf.Profiles {
+		if !slices.Contains(initialLayers, profile.InitialLayer.Name) {
+			initialLayers = append(initialLayers, profile.InitialLayer.Name)
+		}
+	}
+	reachable := make(map[string]bool)
+	var queue []*Layer
+	for _, name := range initialLayers {
+		reachable[name] = true
+		i := slices.IndexFunc(conf.Layers, func(l *Layer) bool { return l.Name == name })
+		queue = append(queue, conf.Layers[i])
+	}
+	// the layers referenced by leader sequences are reachable from all layers with a leader binding
+	var leaderLayers []string
+	for _, sequence := range conf.LeaderSequences {
+		leaderLayers = append(leaderLayers, referencedLayers(sequence.Binding)...)
+	}
+	for len(queue) > 0 {
+		layer := queue[0]
+		queue = queue[1:]
+		forEachLayerBinding(layer, func(_ string, binding Binding, _ Position) {
+			names := referencedLayers(binding)
+			forEachBinding(binding, func(b Binding) {
+				if _, ok := b.(LeaderBinding); ok {
+					names = append(names, leaderLayers...)
+				}
+			})
+			for _, name := range names {
+				if reachable[name] {
+					continue
+				}
+				reachable[name] = true
+				if i := slices.IndexFunc(conf.Layers, func(l *Layer) bool { return l.Name == name }); i >= 0 {
+					queue = append(queue, conf.Layers[i])
+				}
+			}
+		})
+	}
+
+	var problems []error
+	for _, layer := range conf.Layers {
+		isExtended := slices.ContainsFunc(conf.Layers, func(l *Layer) bool { return l.Extends == layer.Name })
+		if !reachable[layer.Name] && !isExtended {
+			problems = append(problems, errorAt(layer.pos, "layer '%s' cannot be reached from the initial layer '%s'",
+				layer.Name, strings.Join(initialLayers, "' or '")))
+		}
+	}
+	return problems
+}
+
+// checkShadowingCombos checks for combos whose keys also have their own binding in the layer, since pressing these
+// keys together within the combo time triggers the combo instead of the single-key bindings (e.g. j+k over j and k).
+// Keys that are only passed through are not reported, since that is the usual way to define combos.
+func checkShadowingCombos(conf *Config) []error {
+	var problems []error
+	for _, layer := range conf.Layers {
+		for _, combo := range layer.ComboBindings {
+			var shadowed []string
+			for _, key := range combo.Keys {
+				if _, ok := layer.Bindings[key]; ok {
+					shadowed = append(shadowed, formatKey(key))
+				}
+			}
+			if len(shadowed) > 0 {
+				problems = append(problems, errorAt(combo.pos, "layer '%s': the combo '%s' shadows the binding of '%s' "+
+					"when pressed within %vms", layer.Name, formatKeys(combo.Keys), strings.Join(shadowed, "' and '"),
+					comboTimeout(conf, combo)))
+			}
+		}
+	}
+	return problems
+}
+
+// checkTapHoldTimeouts checks for tap-hold bindings with a timeout that is shorter than the combo time of a combo
+// that contains the key, since the key press can be delayed by up to the combo time while the combo is pending.
+func checkTapHoldTimeouts(conf *Config) []error {
+	var problems []error
+	for _, layer := range conf.Layers {
+		keys := make([]uint16, 0, len(layer.Bindings))
+		for key := range layer.Bindings {
+			keys = append(keys, key)
+		}
+		slices.Sort(keys)
+		for _, key := range keys {
+			var comboTime float64
+			for _, combo := range layer.ComboBindings {
+				if slices.Contains(combo.Keys, key) {
+					comboTime = max(comboTime, comboTimeout(conf, combo))
+				}
+			}
+			if comboTime == 0 {
+				continue
+			}
+			forEachBinding(layer.Bindings[key], func(b Binding) {
+				if t, ok := b.(TapHoldBinding); ok && t.TimeoutMs > 0 && float64(t.TimeoutMs) < comboTime {
+					problems = append(problems, errorAt(layer.bindingPos[key], "layer '%s': the tap-hold timeout of "+
+						"'%s' (%dms) is shorter than the time of a combo containing it (%vms)", layer.Name,
+						formatKey(key), t.TimeoutMs, comboTime))
+				}
+			})
+		}
+	}
+	return problems
+}
+
+// comboTimeout returns the effective timeout of the given combo in milliseconds, which is the one of the combo or
+// its layer if set, or the global comboTime otherwise.
+func comboTimeout(conf *Config, combo *Combo) float64 {
+	if combo.TimeoutMs > 0 {
+		return float64(combo.TimeoutMs)
+	}
+	return conf.ComboTime
+}
+
+// forEachLayerBinding calls fn for all bindings of the given layer, including combos and the wildcard binding,
+// in a deterministic order. The key is passed in the same form as in the config file, together with the position of
+// the binding.
+func forEachLayerBinding(layer *Layer, fn func(key string, binding Binding, pos Position)) {
+	keys := make([]uint16, 0, len(layer.Bindings))
+	for key := range layer.Bindings {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	for _, key := range keys {
+		fn(formatKey(key), layer.Bindings[key], layer.bindingPos[key])
+	}
+	for _, combo := range layer.ComboBindings {
+		fn(formatKeys(combo.Keys), combo.Binding, combo.pos)
+	}
+	for _, sequence := range layer.SequenceBindings {
+		fn(formatSequenceBinding(sequence.Keys), sequence.Binding, sequence.pos)
+	}
+	if layer.WildcardBinding != nil {
+		fn(formatKey(WildcardKey), layer.WildcardBinding, layer.bindingPos[WildcardKey])
+	}
+}
+
+// forEachBinding calls fn for the given binding and all bindings nested in it.
+func forEachBinding(binding Binding, fn func(Binding)) {
+	fn(binding)
+	switch t := binding.(type) {
+	case MultiBinding:
+		for _, b := range t.Bindings {
+			forEachBinding(b, fn)
+		}
+	case TapHoldBinding:
+		forEachBinding(t.TapBinding, fn)
+		forEachBinding(t.HoldBinding, fn)
+	case TapDanceBinding:
+		for _, b := range slices.Concat(t.TapBindings, t.HoldBindings) {
+			if b != nil {
+				forEachBinding(b, fn)
+			}
+		}
+	}
+}
+
+// referencedLayers returns the names of all layers that are referenced by the given binding.
+func referencedLayers(binding Binding) []string {
+	var names []string
+	forEachBinding(binding, func(b Binding) {
+		switch t := b.(type) {
+		case LayerBinding:
+			names = append(names, t.Layer)
+		case ToggleLayerBinding:
+			names = append(names, t.Layer)
+		case PushLayerBinding:
+			names = append(names, t.Layer)
+		case OneShotLayerBinding:
+			names = append(names, t.Layer)
+		case ModLayerBinding:
+			names = append(names, t.Layer)
+		}
+	})
+	return names
+}
+
+// formatKey returns the alias of the given key, or the code if there is none.
+func formatKey(code uint16) string {
+	if alias, ok := GetKeyAlias(code); ok {
+		return alias
+	}
+	return fmt.Sprintf("%d", code)
+}
+
+// formatSequence formats the given keys as a leader sequence.
+func formatSequence(codes []uint16) string {
+	keys := make([]string, len(codes))
+	for i, code := range codes {
+		keys[i] = formatKey(code)
+	}
+	return strings.Join(keys, " ")
+}
+
+// formatSequenceBinding formats the given keys as a sequence binding of a layer.
+func formatSequenceBinding(codes []uint16) string {
+	keys := make([]string, len(codes))
+	for i, code := range codes {
+		keys[i] = formatKey(code)
+	}
+	return strings.Join(keys, SequenceSeparator)
+}
+
+// formatKeys formats the given keys as a key combo.
+func formatKeys(codes []uint16) string {
+	keys := make([]string, len(codes))
+	for i, code := range codes {
+		keys[i] = formatKey(code)
+	}
+	return strings.Join(keys, "+")
+}
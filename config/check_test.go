@@ -0,0 +1,112 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+// checkConfig parses the given config and returns the messages of all problems found by Check.
+func checkConfig(t *testing.T, configString string) []string {
+	t.Helper()
+	conf, err := ParseConfig([]byte(configString))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var messages []string
+	for _, problem := range Check(conf) {
+		messages = append(messages, problem.Error())
+	}
+	return messages
+}
+
+// assertProblems checks that each problem contains the corresponding expected substring.
+func assertProblems(t *testing.T, problems []string, expected ...string) {
+	t.Helper()
+	if len(problems) != len(expected) {
+		t.Fatalf("expected %d problems but got %d: %q", len(expected), len(problems), problems)
+	}
+	for i := range expected {
+		if !strings.Contains(problems[i], expected[i]) {
+			t.Errorf("expected problem %d to contain '%s' but got '%s'", i, expected[i], problems[i])
+		}
+	}
+}
+
+func TestCheckValid(t *testing.T) {
+	problems := checkConfig(t, `
+layers:
+- name: initial
+  bindings:
+    capslock: layer nav
+- name: nav
+  bindings:
+    esc: layer initial
+`)
+	assertProblems(t, problems)
+}
+
+func TestCheckLayerReferences(t *testing.T) {
+	problems := checkConfig(t, `
+layers:
+- name: initial
+  bindings:
+    a: layer missing
+    b: tap-hold-next c ; toggle-layer other ; 200
+`)
+	assertProblems(t, problems,
+		"line 5, column 8: layer 'initial': the binding of 'a' references the unknown layer 'missing'",
+		"line 6, column 8: layer 'initial': the binding of 'b' references the unknown layer 'other'",
+	)
+}
+
+func TestCheckUnreachableLayers(t *testing.T) {
+	problems := checkConfig(t, `
+layers:
+- name: initial
+  bindings:
+    a: layer nav
+- name: nav
+- name: base
+- name: extended
+  extends: base
+- name: lonely
+`)
+	assertProblems(t, problems, "layer 'extended' cannot be reached", "layer 'lonely' cannot be reached")
+}
+
+func TestCheckShadowingCombos(t *testing.T) {
+	problems := checkConfig(t, `
+layers:
+- name: initial
+  bindings:
+    j: down
+    k: up
+    j+k: esc
+    x+y: enter
+`)
+	assertProblems(t, problems, "line 7, column 10: layer 'initial': the combo 'j+k' shadows the binding of 'j' and 'k' "+
+		"when pressed within 25ms")
+}
+
+func TestCheckTapHoldTimeouts(t *testing.T) {
+	problems := checkConfig(t, `
+comboTime: 100
+layers:
+- name: initial
+  comboTimes:
+    c+d: 20
+  bindings:
+    a: tap-hold a ; leftshift ; 50
+    b: tap-hold b ; leftshift ; 50
+    c: tap-hold c ; leftshift ; 50
+    e: tap-hold e ; leftshift ; 50
+    a+x: esc
+    c+d: enter
+`)
+	assertProblems(t, problems,
+		"the combo 'a+x' shadows the binding of 'a' when",
+		"the combo 'c+d' shadows the binding of 'c' when",
+		"line 8, column 8: layer 'initial': the tap-hold timeout of 'a' (50ms) is shorter than the time of a combo "+
+			"containing it (100ms)",
+	)
+}
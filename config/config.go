@@ -3,13 +3,15 @@ package config
 import (
 	"errors"
 	"fmt"
-	"io"
-	"os"
+	"reflect"
+	"regexp"
+	"slices"
+	"sort"
 	"strconv"
 	"strings"
 
 	log "github.com/sirupsen/logrus"
-	"gopkg.in/yaml.v2"
+	"gopkg.in/yaml.v3"
 )
 
 type Action string
@@ -22,6 +24,9 @@ const (
 	ActionMulti              Action = "multi"
 	ActionLayer              Action = "layer"
 	ActionToggleLayer        Action = "toggle-layer"
+	ActionPushLayer          Action = "push-layer"
+	ActionPopLayer           Action = "pop-layer"
+	ActionLayerBack          Action = "layer-back"
 	ActionReloadConfig       Action = "reload-config"
 	ActionMove               Action = "move"
 	ActionScroll             Action = "scroll"
@@ -30,33 +35,98 @@ const (
 	ActionExec               Action = "exec"
 	ActionExecPressRelease   Action = "exec-press-release"
 	ActionNop                Action = "nop"
+	ActionMacro              Action = "macro"
+	ActionStopMacro          Action = "stop-macro"
+	ActionType               Action = "type"
+	ActionOneShot            Action = "one-shot"
+	ActionOneShotLayer       Action = "one-shot-layer"
+	ActionTapDance           Action = "tap-dance"
+	ActionLeader             Action = "leader"
+	ActionRepeat             Action = "repeat"
+	ActionRepeatCount        Action = "repeat-count"
+	// only used in the structured form, in the string form a key combo is given directly
+	ActionKey Action = "key"
 )
 
+// TransparentMarker can be used as a binding to use the binding of the next active layer in the layer stack.
+// Note that an unquoted ~ is null in YAML, which is why empty bindings are transparent as well.
+const TransparentMarker = "~"
+
+// AliasPrefix marks a binding as a reference to an alias defined in the aliases section.
+const AliasPrefix = "@"
+
+// SequenceSeparator separates the keys of a sequence binding, e.g. j>k.
+const SequenceSeparator = ">"
+
 // RawConfig defines the structure of the config file.
 type RawConfig struct {
-	Devices                []string   `yaml:"devices"`
-	DevicesExclude         []string   `yaml:"devicesExclude"`
-	StartCommand           string     `yaml:"startCommand"`
-	MouseLoopInterval      int64      `yaml:"mouseLoopInterval"`
-	BaseMouseSpeed         float64    `yaml:"baseMouseSpeed"`
-	StartMouseSpeed        float64    `yaml:"startMouseSpeed"`
-	MouseAccelerationCurve float64    `yaml:"mouseAccelerationCurve"`
-	MouseAccelerationTime  float64    `yaml:"mouseAccelerationTime"`
-	MouseDecelerationCurve float64    `yaml:"mouseDecelerationCurve"`
-	MouseDecelerationTime  float64    `yaml:"mouseDecelerationTime"`
-	BaseScrollSpeed        float64    `yaml:"baseScrollSpeed"`
-	QuickTapTime           float64    `yaml:"quickTapTime"`
-	ComboTime              float64    `yaml:"comboTime"`
-	InstanceName           string     `yaml:"instanceName"`
-	Layers                 []RawLayer `yaml:"layers"`
+	Devices                []string          `yaml:"devices"`
+	DevicesExclude         []string          `yaml:"devicesExclude"`
+	StartCommand           string            `yaml:"startCommand"`
+	MouseLoopInterval      int64             `yaml:"mouseLoopInterval"`
+	BaseMouseSpeed         float64           `yaml:"baseMouseSpeed"`
+	StartMouseSpeed        float64           `yaml:"startMouseSpeed"`
+	MouseAccelerationCurve float64           `yaml:"mouseAccelerationCurve"`
+	MouseAccelerationTime  float64           `yaml:"mouseAccelerationTime"`
+	MouseDecelerationCurve float64           `yaml:"mouseDecelerationCurve"`
+	MouseDecelerationTime  float64           `yaml:"mouseDecelerationTime"`
+	BaseScrollSpeed        float64           `yaml:"baseScrollSpeed"`
+	QuickTapTime           float64           `yaml:"quickTapTime"`
+	ComboTime              float64           `yaml:"comboTime"`
+	SequenceTime           float64           `yaml:"sequenceTime"`
+	OneShotTimeout         float64           `yaml:"oneShotTimeout"`
+	OneShotCancelOnEscape  *bool             `yaml:"oneShotCancelOnEscape"`
+	LeaderTimeout          float64           `yaml:"leaderTimeout"`
+	LeaderReplayUnknown    *bool             `yaml:"leaderReplayUnknown"`
+	RepeatDelay            float64           `yaml:"repeatDelay"`
+	RepeatRate             float64           `yaml:"repeatRate"`
+	LeaderSequences        RawBindings       `yaml:"leaderSequences"`
+	InstanceName           string            `yaml:"instanceName"`
+	Include                []string          `yaml:"include"`
+	Aliases                RawBindings       `yaml:"aliases"`
+	KeyAliases             map[string]string `yaml:"keyAliases"`
+	TypeLayout             string            `yaml:"typeLayout"`
+	TypeLayoutKeys         map[string]string `yaml:"typeLayoutKeys"`
+	TypeUnicodeInput       string            `yaml:"typeUnicodeInput"`
+	Profiles               []RawProfile      `yaml:"profiles"`
+	Layers                 []RawLayer        `yaml:"layers"`
+
+	// the absolute paths of the file and all files it includes
+	files []string
 }
 
 type RawLayer struct {
-	Name         string            `yaml:"name"`
-	PassThrough  *bool             `yaml:"passThrough"`
-	EnterCommand *string           `yaml:"enterCommand"`
-	ExitCommand  *string           `yaml:"exitCommand"`
-	Bindings     map[string]string `yaml:"bindings"`
+	Name         string             `yaml:"name"`
+	PassThrough  *bool              `yaml:"passThrough"`
+	EnterCommand *string            `yaml:"enterCommand"`
+	ExitCommand  *string            `yaml:"exitCommand"`
+	Extends      string             `yaml:"extends"`
+	ComboTime    float64            `yaml:"comboTime"`
+	ComboTimes   map[string]float64 `yaml:"comboTimes"`
+	Repeat       *bool              `yaml:"repeat"`
+	RepeatKeys   []string           `yaml:"repeatKeys"`
+	Bindings     RawBindings        `yaml:"bindings"`
+	// the mouse parameters that are overridden in this layer
+	RawMouseParameters `yaml:",inline"`
+
+	pos Position
+}
+
+// RawMouseParameters are the mouse parameters of a layer, where nil means that the global value is used.
+type RawMouseParameters struct {
+	BaseMouseSpeed         *float64 `yaml:"baseMouseSpeed"`
+	StartMouseSpeed        *float64 `yaml:"startMouseSpeed"`
+	MouseAccelerationCurve *float64 `yaml:"mouseAccelerationCurve"`
+	MouseAccelerationTime  *float64 `yaml:"mouseAccelerationTime"`
+	MouseDecelerationCurve *float64 `yaml:"mouseDecelerationCurve"`
+	MouseDecelerationTime  *float64 `yaml:"mouseDecelerationTime"`
+	BaseScrollSpeed        *float64 `yaml:"baseScrollSpeed"`
+}
+
+type RawProfile struct {
+	Name         string   `yaml:"name"`
+	Devices      []string `yaml:"devices"`
+	InitialLayer string   `yaml:"initialLayer"`
 }
 
 // Config is the parsed form of RawConfig.
@@ -67,6 +137,13 @@ type Config struct {
 	MouseLoopInterval      int64
 	QuickTapTime           float64
 	ComboTime              float64
+	SequenceTime           float64
+	OneShotTimeout         float64
+	OneShotCancelOnEscape  bool
+	LeaderTimeout          float64
+	LeaderReplayUnknown    bool
+	RepeatDelay            float64
+	RepeatRate             float64 // 0 means that the repeat events of the keyboard are used
 	BaseMouseSpeed         float64
 	MouseAccelerationCurve float64
 	MouseAccelerationTime  float64
@@ -76,6 +153,12 @@ type Config struct {
 	BaseScrollSpeed        float64
 	InstanceName           string
 	Layers                 []*Layer
+	// LeaderSequences are the key sequences that can be typed after a leader key
+	LeaderSequences []*LeaderSequence
+	// Profiles assign an own layer state to the matching devices, other devices share the default layer state
+	Profiles []*Profile
+	// Files contains the absolute paths of the config file and all files it includes, empty if parsed from bytes
+	Files []string
 }
 
 type Layer struct {
@@ -83,9 +166,65 @@ type Layer struct {
 	PassThrough     bool // default true
 	EnterCommand    *string
 	ExitCommand     *string
+	Extends         string  // name of the layer whose bindings are inherited
+	ComboTime       float64 // 0 means that the global comboTime is used
+	Repeat          bool    // whether all bindings are repeated while their key is held
+	RepeatKeys      []uint16
 	Bindings        map[uint16]Binding
-	ComboBindings   map[uint16]map[uint16]Binding
+	ComboBindings   []*Combo // sorted by the number of keys, longest first
 	WildcardBinding Binding
+	// the bindings of keys that are typed one after another, e.g. j>k, sorted by their keys
+	SequenceBindings []*Sequence
+	// the global mouse parameters with the overrides of the layer applied
+	MouseParameters MouseParameters
+
+	pos            Position
+	bindingPos     map[uint16]Position
+	mouseOverrides RawMouseParameters
+}
+
+// MouseParameters are the parameters for the mouse movement and scrolling.
+type MouseParameters struct {
+	BaseMouseSpeed         float64
+	StartMouseSpeed        float64
+	MouseAccelerationCurve float64
+	MouseAccelerationTime  float64
+	MouseDecelerationCurve float64
+	MouseDecelerationTime  float64
+	BaseScrollSpeed        float64
+}
+
+// Profile is a layer state of its own for the matching devices, starting with the given initial layer.
+type Profile struct {
+	Name         string
+	Devices      []string // names or paths of devices, as in Config.Devices
+	InitialLayer *Layer
+}
+
+// LeaderSequence is a binding that is triggered when its keys are typed one after another after a leader key.
+type LeaderSequence struct {
+	Keys    []uint16
+	Binding Binding
+
+	pos Position
+}
+
+// Sequence is a binding that is triggered when its keys are typed one after another within the sequence time, without
+// other keys in between.
+type Sequence struct {
+	Keys    []uint16
+	Binding Binding
+
+	pos Position
+}
+
+// Combo is a binding that is triggered when all of its keys are pressed simultaneously.
+type Combo struct {
+	Keys      []uint16
+	Binding   Binding
+	TimeoutMs int64 // 0 means that the global comboTime is used
+
+	pos Position
 }
 
 type Binding interface {
@@ -111,6 +250,37 @@ type TapHoldBinding struct {
 	TapOnNextRelease bool
 }
 
+// TapDanceBinding chooses a binding depending on how many times the key is tapped, where each tap has to follow
+// within the timeout. The n-th entry of TapBindings is used for n taps and the n-th entry of HoldBindings if the key is
+// held on the n-th press, entries may be nil.
+type TapDanceBinding struct {
+	BaseBinding
+	TapBindings  []Binding
+	HoldBindings []Binding
+	TimeoutMs    int64
+}
+
+// MaxTaps returns the highest number of taps that has a binding.
+func (b TapDanceBinding) MaxTaps() int {
+	return max(len(b.TapBindings), len(b.HoldBindings))
+}
+
+// Binding returns the binding for the given number of taps, where the hold binding falls back to the tap binding and
+// vice versa. It returns nil if there is no binding for the count.
+func (b TapDanceBinding) Binding(count int, held bool) Binding {
+	var tap, hold Binding
+	if count <= len(b.TapBindings) {
+		tap = b.TapBindings[count-1]
+	}
+	if count <= len(b.HoldBindings) {
+		hold = b.HoldBindings[count-1]
+	}
+	if (held && hold != nil) || tap == nil {
+		return hold
+	}
+	return tap
+}
+
 type ModLayerBinding struct {
 	BaseBinding
 	ModKey uint16
@@ -128,6 +298,19 @@ type ToggleLayerBinding struct {
 	BaseBinding
 	Layer string
 }
+type PushLayerBinding struct {
+	BaseBinding
+	Layer string
+}
+type PopLayerBinding struct {
+	BaseBinding
+}
+type LayerBackBinding struct {
+	BaseBinding
+}
+type TransparentBinding struct {
+	BaseBinding
+}
 type ReloadConfigBinding struct {
 	BaseBinding
 }
@@ -161,6 +344,69 @@ type ExecPressReleaseBinding struct {
 	ReleaseCommand string
 }
 
+// MacroBinding plays a sequence of steps, e.g. key taps and waits.
+type MacroBinding struct {
+	BaseBinding
+	Steps []MacroStep
+}
+
+type MacroStepType int
+
+const (
+	// MacroStepTap presses and releases a key combo
+	MacroStepTap MacroStepType = iota
+	// MacroStepPress presses a key combo without releasing it
+	MacroStepPress
+	// MacroStepRelease releases a key combo
+	MacroStepRelease
+	// MacroStepWait waits for WaitMs milliseconds
+	MacroStepWait
+)
+
+// StopMacroBinding cancels the macro that is currently played.
+type StopMacroBinding struct {
+	BaseBinding
+}
+
+// MacroStep is a single step of a MacroBinding.
+type MacroStep struct {
+	Type   MacroStepType
+	Keys   []uint16
+	WaitMs int64
+}
+
+// TypeBinding types a text, the steps are the key taps that produce it with the configured layout.
+type TypeBinding struct {
+	BaseBinding
+	Text  string
+	Steps []MacroStep
+}
+
+// OneShotBinding presses keys (usually modifiers) that are released after the next key press, or like normal keys if
+// it is held while pressing other keys.
+type OneShotBinding struct {
+	BaseBinding
+	Keys []uint16
+}
+
+// OneShotLayerBinding activates a layer until the next key press, or like ToggleLayerBinding if it is held while
+// pressing other keys.
+type OneShotLayerBinding struct {
+	BaseBinding
+	Layer string
+}
+
+// LeaderBinding starts a leader sequence.
+type LeaderBinding struct {
+	BaseBinding
+}
+
+// RepeatBinding executes the last executed binding that is repeatable again, Count times.
+type RepeatBinding struct {
+	BaseBinding
+	Count int
+}
+
 // these are only used internally
 type KeyPressBinding struct {
 	BaseBinding
@@ -171,39 +417,241 @@ type KeyReleaseBinding struct {
 	Key uint16
 }
 
-// ReadConfig reads and parses the configuration from the given file.
+// OneShotReleaseBinding releases the keys of OneShotBindings and deactivates the layers of OneShotLayerBindings.
+type OneShotReleaseBinding struct {
+	BaseBinding
+	Keys   []uint16
+	Layers []string
+}
+
+// RepeatableBinding returns the parts of the given binding that can be repeated, or nil if there are none. Layer
+// changes, config reloads and the bindings that are only used internally are not repeatable.
+func RepeatableBinding(binding Binding, causeCode uint16) Binding {
+	switch t := binding.(type) {
+	case KeyBinding:
+		// the wildcard refers to the key that has been pressed originally
+		keys := slices.Clone(t.KeyCombo)
+		for i, key := range keys {
+			if key == WildcardKey {
+				keys[i] = causeCode
+			}
+		}
+		return KeyBinding{KeyCombo: keys}
+	case MoveBinding, ScrollBinding, ButtonBinding, ExecBinding, ExecPressReleaseBinding, MacroBinding, TypeBinding:
+		return binding
+	case MultiBinding:
+		var bindings []Binding
+		for _, binding := range t.Bindings {
+			if repeatable := RepeatableBinding(binding, causeCode); repeatable != nil {
+				bindings = append(bindings, repeatable)
+			}
+		}
+		if len(bindings) == 0 {
+			return nil
+		} else if len(bindings) == 1 {
+			return bindings[0]
+		}
+		return MultiBinding{Bindings: bindings}
+	}
+	return nil
+}
+
+// ReadConfig reads and parses the configuration from the given file, including all files it includes.
 func ReadConfig(fileName string) (*Config, error) {
-	// read the file
-	configFile, err := os.Open(fileName)
+	rawConfig, err := readRawConfig(fileName, nil)
 	if err != nil {
 		return nil, err
 	}
-	defer configFile.Close()
-
-	configString, err := io.ReadAll(configFile)
+	config, err := parseRawConfig(rawConfig)
 	if err != nil {
+		// make sure that the file is part of the error message
+		var posErr *PositionError
+		if !errors.As(err, &posErr) {
+			err = &PositionError{Pos: Position{File: fileName}, Err: err}
+		}
 		return nil, err
 	}
-
-	return ParseConfig(configString)
+	return config, nil
 }
 
 // ParseConfig parses the given configuration.
+// Since there is no file to resolve them against, includes are not supported.
 func ParseConfig(configBytes []byte) (*Config, error) {
-	var rawConfig RawConfig
-	err := yaml.Unmarshal(configBytes, &rawConfig)
+	rawConfig, err := unmarshalRawConfig(configBytes, "")
 	if err != nil {
 		return nil, err
 	}
-	// log a warning for unknown or duplicate keys
-	err = yaml.UnmarshalStrict(configBytes, &rawConfig)
-	var typeError *yaml.TypeError
-	if errors.As(err, &typeError) {
-		for _, e := range typeError.Errors {
-			log.Warnf("problem in the config file: %v", e)
+	if len(rawConfig.Include) > 0 {
+		return nil, fmt.Errorf("includes are only supported in config files")
+	}
+	return parseRawConfig(rawConfig)
+}
+
+var yamlErrorRegex = regexp.MustCompile(`^yaml: line (\d+): (.*)$`)
+
+// unmarshalRawConfig unmarshals the given configuration, fileName is used for the positions and log messages.
+func unmarshalRawConfig(configBytes []byte, fileName string) (*RawConfig, error) {
+	var document yaml.Node
+	if err := yaml.Unmarshal(configBytes, &document); err != nil {
+		// syntax errors only contain the line, e.g. "yaml: line 4: did not find expected node content"
+		if m := yamlErrorRegex.FindStringSubmatch(err.Error()); m != nil {
+			line, _ := strconv.Atoi(m[1])
+			return nil, errorAt(Position{File: fileName, Line: line}, "%s", m[2])
+		}
+		return nil, &PositionError{Pos: Position{File: fileName}, Err: err}
+	}
+	// duplicate and unknown keys are only reported, and like in earlier versions the last duplicate key wins
+	for _, warning := range checkKeys(&document, fileName) {
+		log.Warnf("problem in the config file: %v", warning)
+	}
+	var rawConfig RawConfig
+	if document.Kind != 0 {
+		if err := document.Decode(&rawConfig); err != nil {
+			return nil, &PositionError{Pos: Position{File: fileName}, Err: err}
 		}
 	}
 
+	// the positions of the layers are taken from the document node
+	if len(document.Content) > 0 {
+		root := document.Content[0]
+		for i := 0; i+1 < len(root.Content); i += 2 {
+			if root.Content[i].Value == "layers" {
+				for j, layerNode := range root.Content[i+1].Content {
+					if j < len(rawConfig.Layers) {
+						rawConfig.Layers[j].pos = Position{Line: layerNode.Line, Column: layerNode.Column}
+					}
+				}
+			}
+		}
+	}
+	rawConfig.setFile(fileName)
+
+	// layers are merged by name across files, so a duplicate within a file would silently shadow the other one
+	layerPositions := make(map[string]Position)
+	for _, layer := range rawConfig.Layers {
+		if pos, ok := layerPositions[layer.Name]; ok && layer.Name != "" {
+			return nil, errorAt(layer.pos, "the layer '%s' is already defined at line %d", layer.Name, pos.Line)
+		}
+		layerPositions[layer.Name] = layer.pos
+	}
+
+	return &rawConfig, nil
+}
+
+// checkKeys removes all but the last occurrence of duplicate keys in the given document and returns a warning for
+// each of them, as well as for all keys that are not known in the config file.
+func checkKeys(document *yaml.Node, fileName string) []error {
+	warnings := removeDuplicateKeys(document, fileName)
+	if len(document.Content) > 0 {
+		warnings = append(warnings, findUnknownKeys(document.Content[0], reflect.TypeOf(RawConfig{}), fileName)...)
+	}
+	return warnings
+}
+
+// removeDuplicateKeys removes all but the last occurrence of duplicate keys in all mappings of the given node, and
+// returns a warning for each removed key.
+func removeDuplicateKeys(node *yaml.Node, fileName string) []error {
+	var warnings []error
+	if node.Kind == yaml.MappingNode {
+		var content []*yaml.Node
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key := node.Content[i]
+			isDuplicate := false
+			for j := i + 2; j+1 < len(node.Content); j += 2 {
+				if next := node.Content[j]; next.Value == key.Value {
+					warnings = append(warnings, errorAt(Position{File: fileName, Line: next.Line, Column: next.Column},
+						"the key '%s' is already defined at line %d, the last one is used", key.Value, key.Line))
+					isDuplicate = true
+					break
+				}
+			}
+			if !isDuplicate {
+				content = append(content, key, node.Content[i+1])
+			}
+		}
+		node.Content = content
+	}
+	for _, child := range node.Content {
+		warnings = append(warnings, removeDuplicateKeys(child, fileName)...)
+	}
+	return warnings
+}
+
+var unmarshalerType = reflect.TypeOf((*yaml.Unmarshaler)(nil)).Elem()
+
+// findUnknownKeys returns a warning for each key of the mappings in the given node that has no field in the given
+// type. Types that unmarshal themselves, like RawBinding, are not checked.
+func findUnknownKeys(node *yaml.Node, t reflect.Type, fileName string) []error {
+	if node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if reflect.PointerTo(t).Implements(unmarshalerType) {
+		return nil
+	}
+	var warnings []error
+	switch {
+	case t.Kind() == reflect.Struct && node.Kind == yaml.MappingNode:
+		fields := yamlFields(t)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key := node.Content[i]
+			field, ok := fields[key.Value]
+			if !ok {
+				warnings = append(warnings, errorAt(Position{File: fileName, Line: key.Line, Column: key.Column},
+					"unknown key '%s'", key.Value))
+				continue
+			}
+			warnings = append(warnings, findUnknownKeys(node.Content[i+1], field, fileName)...)
+		}
+	case t.Kind() == reflect.Map && node.Kind == yaml.MappingNode:
+		for i := 1; i < len(node.Content); i += 2 {
+			warnings = append(warnings, findUnknownKeys(node.Content[i], t.Elem(), fileName)...)
+		}
+	case t.Kind() == reflect.Slice && node.Kind == yaml.SequenceNode:
+		for _, item := range node.Content {
+			warnings = append(warnings, findUnknownKeys(item, t.Elem(), fileName)...)
+		}
+	}
+	return warnings
+}
+
+// yamlFields returns the types of the fields of the given struct by their yaml key, including inlined structs.
+func yamlFields(t reflect.Type) map[string]reflect.Type {
+	fields := make(map[string]reflect.Type)
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, options, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+		if options == "inline" {
+			for key, inlined := range yamlFields(field.Type) {
+				fields[key] = inlined
+			}
+		} else if name != "" && name != "-" {
+			fields[name] = field.Type
+		}
+	}
+	return fields
+}
+
+// setFile sets the file of all positions in the RawConfig.
+func (c *RawConfig) setFile(fileName string) {
+	for name, binding := range c.Aliases {
+		c.Aliases[name] = binding.withFile(fileName)
+	}
+	for sequence, binding := range c.LeaderSequences {
+		c.LeaderSequences[sequence] = binding.withFile(fileName)
+	}
+	for i := range c.Layers {
+		c.Layers[i].pos.File = fileName
+		for key, binding := range c.Layers[i].Bindings {
+			c.Layers[i].Bindings[key] = binding.withFile(fileName)
+		}
+	}
+}
+
+// parseRawConfig converts the given RawConfig to a Config.
+func parseRawConfig(rawConfig *RawConfig) (*Config, error) {
 	config := Config{
 		MouseAccelerationCurve: 1.0,
 		MouseDecelerationCurve: 1.0,
@@ -228,57 +676,139 @@ func ParseConfig(configBytes []byte) (*Config, error) {
 	config.StartMouseSpeed = rawConfig.StartMouseSpeed
 	config.BaseScrollSpeed = rawConfig.BaseScrollSpeed
 	config.InstanceName = rawConfig.InstanceName
+	config.Files = rawConfig.files
 	config.QuickTapTime = rawConfig.QuickTapTime
 	if rawConfig.ComboTime > 0 {
 		config.ComboTime = rawConfig.ComboTime
 	} else {
 		config.ComboTime = 25
 	}
+	if rawConfig.SequenceTime > 0 {
+		config.SequenceTime = rawConfig.SequenceTime
+	} else {
+		config.SequenceTime = 150
+	}
+	config.OneShotTimeout = rawConfig.OneShotTimeout
+	config.OneShotCancelOnEscape = rawConfig.OneShotCancelOnEscape == nil || *rawConfig.OneShotCancelOnEscape
+	if rawConfig.LeaderTimeout > 0 {
+		config.LeaderTimeout = rawConfig.LeaderTimeout
+	} else {
+		config.LeaderTimeout = 1000
+	}
+	config.LeaderReplayUnknown = rawConfig.LeaderReplayUnknown != nil && *rawConfig.LeaderReplayUnknown
+	if rawConfig.RepeatDelay < 0 || rawConfig.RepeatRate < 0 {
+		return nil, fmt.Errorf("repeatDelay and repeatRate must not be negative")
+	}
+	if rawConfig.RepeatDelay > 0 {
+		config.RepeatDelay = rawConfig.RepeatDelay
+	} else {
+		config.RepeatDelay = 300
+	}
+	config.RepeatRate = rawConfig.RepeatRate
 	if len(rawConfig.Layers) == 0 {
 		return nil, fmt.Errorf("no layers defined")
 	}
-	for i, l := range rawConfig.Layers {
-		layer, err := parseLayer(l)
+	keyAliases, err := parseKeyAliases(rawConfig.KeyAliases)
+	if err != nil {
+		return nil, err
+	}
+	parser := newBindingParser(rawConfig.Aliases, keyAliases)
+	parser.typeLayout, err = parser.parseTypeLayout(rawConfig.TypeLayout, rawConfig.TypeLayoutKeys,
+		rawConfig.TypeUnicodeInput)
+	if err != nil {
+		return nil, err
+	}
+	if err := parser.parseAliases(); err != nil {
+		return nil, err
+	}
+	for _, l := range rawConfig.Layers {
+		layer, err := parseLayer(l, parser)
 		if err != nil {
-			return nil, fmt.Errorf("failed to parse layer %v : %v", i, err)
+			return nil, err
 		}
 		config.Layers = append(config.Layers, layer)
 	}
+	if err := resolveExtends(config.Layers); err != nil {
+		return nil, err
+	}
+	if config.Profiles, err = parseProfiles(rawConfig.Profiles, config.Layers); err != nil {
+		return nil, err
+	}
+	if config.LeaderSequences, err = parser.parseLeaderSequences(rawConfig.LeaderSequences); err != nil {
+		return nil, err
+	}
+	for _, layer := range config.Layers {
+		layer.MouseParameters = layer.mouseOverrides.apply(MouseParameters{
+			BaseMouseSpeed:         config.BaseMouseSpeed,
+			StartMouseSpeed:        config.StartMouseSpeed,
+			MouseAccelerationCurve: config.MouseAccelerationCurve,
+			MouseAccelerationTime:  config.MouseAccelerationTime,
+			MouseDecelerationCurve: config.MouseDecelerationCurve,
+			MouseDecelerationTime:  config.MouseDecelerationTime,
+			BaseScrollSpeed:        config.BaseScrollSpeed,
+		})
+	}
 
 	log.Debugf("config: %+v", config)
 	return &config, nil
 }
 
+// IsRepeated checks if the binding of the given key is repeated while the key is held.
+func (l *Layer) IsRepeated(code uint16) bool {
+	return l.Repeat || slices.Contains(l.RepeatKeys, code)
+}
+
 // parseLayer parses a single RawLayer to Layer.
-func parseLayer(rawLayer RawLayer) (*Layer, error) {
+func parseLayer(rawLayer RawLayer, parser *bindingParser) (*Layer, error) {
 	var layer Layer
 
 	if rawLayer.Name == "" {
-		return nil, fmt.Errorf("no name given")
+		return nil, errorAt(rawLayer.pos, "failed to parse layer: no name given")
 	}
 
 	layer.Name = rawLayer.Name
+	layer.pos = rawLayer.pos
 	layer.EnterCommand = rawLayer.EnterCommand
 	layer.ExitCommand = rawLayer.ExitCommand
+	layer.Extends = rawLayer.Extends
+	if rawLayer.ComboTime < 0 {
+		return nil, errorAt(rawLayer.pos, "layer '%s': comboTime must not be negative", rawLayer.Name)
+	}
+	layer.ComboTime = rawLayer.ComboTime
 	layer.Bindings = make(map[uint16]Binding)
-	layer.ComboBindings = make(map[uint16]map[uint16]Binding)
+	layer.bindingPos = make(map[uint16]Position)
+	if err := rawLayer.RawMouseParameters.validate(); err != nil {
+		return nil, errorAt(rawLayer.pos, "layer '%s': %v", rawLayer.Name, err)
+	}
+	layer.mouseOverrides = rawLayer.RawMouseParameters
 	if rawLayer.PassThrough == nil {
 		layer.PassThrough = true
 	} else {
 		layer.PassThrough = *rawLayer.PassThrough
 	}
 
-	if rawLayer.Bindings == nil {
-		rawLayer.Bindings = make(map[string]string)
-	}
 	for key, bind := range rawLayer.Bindings {
-		codes, err := parseKeyCombo(key)
-		if err != nil {
-			return nil, fmt.Errorf("failed to parse the key '%v': %v", key, err)
+		var binding Binding
+		var err error
+		if bind.isTransparent() {
+			binding = TransparentBinding{}
+		} else {
+			binding, err = parser.parseRawBinding(bind)
+			if err != nil {
+				return nil, errorAt(bind.pos, "failed to parse the binding '%v': %v", bind, err)
+			}
+		}
+		if strings.Contains(key, SequenceSeparator) {
+			codes, err := parser.parseSequence(key)
+			if err != nil {
+				return nil, errorAt(bind.pos, "failed to parse the sequence '%v': %v", key, err)
+			}
+			layer.SequenceBindings = append(layer.SequenceBindings, &Sequence{Keys: codes, Binding: binding, pos: bind.pos})
+			continue
 		}
-		binding, err := parseBinding(bind)
+		codes, err := parser.parseKeyCombo(key)
 		if err != nil {
-			return nil, fmt.Errorf("failed to parse the binding '%v': %v", bind, err)
+			return nil, errorAt(bind.pos, "failed to parse the key '%v': %v", key, err)
 		}
 		if len(codes) == 1 {
 			if codes[0] == WildcardKey {
@@ -286,28 +816,371 @@ func parseLayer(rawLayer RawLayer) (*Layer, error) {
 			} else {
 				layer.Bindings[codes[0]] = binding
 			}
-		} else if len(codes) == 2 {
-			if _, ok := layer.ComboBindings[codes[0]]; !ok {
-				layer.ComboBindings[codes[0]] = make(map[uint16]Binding)
-			}
-			if _, ok := layer.ComboBindings[codes[1]]; !ok {
-				layer.ComboBindings[codes[1]] = make(map[uint16]Binding)
-			}
-			layer.ComboBindings[codes[0]][codes[1]] = binding
-			layer.ComboBindings[codes[1]][codes[0]] = binding
+			layer.bindingPos[codes[0]] = bind.pos
 		} else {
-			return nil, fmt.Errorf("combos with more than 2 keys are not supported: '%v'", key)
+			for i, code := range codes {
+				if code == WildcardKey {
+					return nil, errorAt(bind.pos, "the wildcard key cannot be part of a combo: '%v'", key)
+				}
+				if slices.Contains(codes[:i], code) {
+					return nil, errorAt(bind.pos, "duplicate key in combo: '%v'", key)
+				}
+			}
+			layer.ComboBindings = append(layer.ComboBindings, &Combo{
+				Keys:      codes,
+				Binding:   binding,
+				TimeoutMs: int64(layer.ComboTime),
+				pos:       bind.pos,
+			})
 		}
 	}
+	sortCombos(layer.ComboBindings)
+	sortSequences(layer.SequenceBindings)
+
+	for key, timeout := range rawLayer.ComboTimes {
+		codes, err := parser.parseKeyCombo(key)
+		if err != nil {
+			return nil, errorAt(rawLayer.pos, "failed to parse the combo '%v' in comboTimes: %v", key, err)
+		}
+		if timeout <= 0 {
+			return nil, errorAt(rawLayer.pos, "the combo time of '%v' must be positive", key)
+		}
+		combo := FindCombo(layer.ComboBindings, codes)
+		if combo == nil {
+			return nil, errorAt(rawLayer.pos, "comboTimes contains '%v', which is not a combo of the layer", key)
+		}
+		combo.TimeoutMs = int64(timeout)
+	}
+
+	layer.Repeat = rawLayer.Repeat != nil && *rawLayer.Repeat
+	for _, key := range rawLayer.RepeatKeys {
+		code, err := parser.parseKey(key)
+		if err != nil {
+			return nil, errorAt(rawLayer.pos, "failed to parse the key '%v' in repeatKeys: %v", key, err)
+		}
+		layer.RepeatKeys = append(layer.RepeatKeys, code)
+	}
 
 	return &layer, nil
 }
 
+// parseProfiles parses the given profiles, the initial layer of a profile defaults to the first layer.
+func parseProfiles(rawProfiles []RawProfile, layers []*Layer) ([]*Profile, error) {
+	var profiles []*Profile
+	for i, rawProfile := range rawProfiles {
+		if rawProfile.Name == "" {
+			return nil, fmt.Errorf("profile %d has no name", i)
+		}
+		if slices.ContainsFunc(profiles, func(p *Profile) bool { return p.Name == rawProfile.Name }) {
+			return nil, fmt.Errorf("duplicate profile '%s'", rawProfile.Name)
+		}
+		if len(rawProfile.Devices) == 0 {
+			return nil, fmt.Errorf("profile '%s' has no devices", rawProfile.Name)
+		}
+		profile := &Profile{Name: rawProfile.Name, Devices: rawProfile.Devices, InitialLayer: layers[0]}
+		if rawProfile.InitialLayer != "" {
+			i := slices.IndexFunc(layers, func(l *Layer) bool { return l.Name == rawProfile.InitialLayer })
+			if i < 0 {
+				return nil, fmt.Errorf("profile '%s' has the unknown initial layer '%s'", rawProfile.Name,
+					rawProfile.InitialLayer)
+			}
+			profile.InitialLayer = layers[i]
+		}
+		profiles = append(profiles, profile)
+	}
+	return profiles, nil
+}
+
+// parseLeaderSequences parses the leader sequences, which are given as key names separated by spaces, e.g. "g s".
+func (p *bindingParser) parseLeaderSequences(rawSequences map[string]RawBinding) ([]*LeaderSequence, error) {
+	rawKeys := make([]string, 0, len(rawSequences))
+	for rawKey := range rawSequences {
+		rawKeys = append(rawKeys, rawKey)
+	}
+	sort.Strings(rawKeys)
+
+	var sequences []*LeaderSequence
+	for _, rawKey := range rawKeys {
+		rawBinding := rawSequences[rawKey]
+		sequence := &LeaderSequence{pos: rawBinding.pos}
+		fields := strings.Fields(rawKey)
+		if len(fields) == 0 {
+			return nil, errorAt(rawBinding.pos, "leader sequence is empty")
+		}
+		for _, field := range fields {
+			key, err := p.parseKey(field)
+			if err != nil {
+				return nil, errorAt(rawBinding.pos, "failed to parse the key '%s' of the leader sequence '%s': %v",
+					field, rawKey, err)
+			}
+			sequence.Keys = append(sequence.Keys, key)
+		}
+		if slices.ContainsFunc(sequences, func(s *LeaderSequence) bool { return slices.Equal(s.Keys, sequence.Keys) }) {
+			return nil, errorAt(rawBinding.pos, "the leader sequence '%s' is defined twice", rawKey)
+		}
+		binding, err := p.parseRawBinding(rawBinding)
+		if err != nil {
+			var aliasErr *aliasError
+			if errors.As(err, &aliasErr) {
+				return nil, err
+			}
+			return nil, errorAt(rawBinding.pos, "failed to parse the binding of the leader sequence '%s': %v",
+				rawKey, err)
+		}
+		sequence.Binding = binding
+		sequences = append(sequences, sequence)
+	}
+	return sequences, nil
+}
+
+// resolveExtends adds the bindings of the extended layers to all layers that extend another one, unless they are
+// bound in the layer itself. This is done recursively, so a layer inherits from the whole chain of extended layers.
+func resolveExtends(layers []*Layer) error {
+	resolved := make(map[*Layer]bool)
+	var resolve func(layer *Layer, chain []string) error
+	resolve = func(layer *Layer, chain []string) error {
+		if resolved[layer] || layer.Extends == "" {
+			return nil
+		}
+		chain = append(chain, layer.Name)
+		if slices.Contains(chain[:len(chain)-1], layer.Name) {
+			return errorAt(layer.pos, "layer inheritance cycle: %s", strings.Join(chain, " -> "))
+		}
+		i := slices.IndexFunc(layers, func(l *Layer) bool { return l.Name == layer.Extends })
+		if i < 0 {
+			return errorAt(layer.pos, "layer '%s' extends the unknown layer '%s'", layer.Name, layer.Extends)
+		}
+		parent := layers[i]
+		if parent.Extends != "" {
+			if err := resolve(parent, chain); err != nil {
+				return err
+			}
+		}
+
+		for code, binding := range parent.Bindings {
+			if _, ok := layer.Bindings[code]; !ok {
+				layer.Bindings[code] = binding
+				layer.bindingPos[code] = parent.bindingPos[code]
+				// inherited bindings keep repeating
+				if parent.IsRepeated(code) && !layer.IsRepeated(code) {
+					layer.RepeatKeys = append(layer.RepeatKeys, code)
+				}
+			}
+		}
+		if layer.WildcardBinding == nil && parent.WildcardBinding != nil {
+			layer.WildcardBinding = parent.WildcardBinding
+			layer.bindingPos[WildcardKey] = parent.bindingPos[WildcardKey]
+		}
+		for _, combo := range parent.ComboBindings {
+			if FindCombo(layer.ComboBindings, combo.Keys) == nil {
+				layer.ComboBindings = append(layer.ComboBindings, combo)
+			}
+		}
+		sortCombos(layer.ComboBindings)
+		for _, sequence := range parent.SequenceBindings {
+			if FindSequence(layer.SequenceBindings, sequence.Keys) == nil {
+				layer.SequenceBindings = append(layer.SequenceBindings, sequence)
+			}
+		}
+		sortSequences(layer.SequenceBindings)
+		layer.mouseOverrides = parent.mouseOverrides.overriddenBy(layer.mouseOverrides)
+
+		resolved[layer] = true
+		return nil
+	}
+
+	for _, layer := range layers {
+		if err := resolve(layer, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validate checks that the given mouse parameters are in their valid ranges.
+func (p RawMouseParameters) validate() error {
+	for _, curve := range []*float64{p.MouseAccelerationCurve, p.MouseDecelerationCurve} {
+		if curve != nil && *curve <= 0 {
+			return fmt.Errorf("the mouse acceleration and deceleration curves must be positive")
+		}
+	}
+	for _, value := range []*float64{p.BaseMouseSpeed, p.StartMouseSpeed, p.MouseAccelerationTime,
+		p.MouseDecelerationTime, p.BaseScrollSpeed} {
+		if value != nil && *value < 0 {
+			return fmt.Errorf("the mouse speeds and times must not be negative")
+		}
+	}
+	return nil
+}
+
+// overriddenBy returns the parameters where all parameters that are set in other are replaced.
+func (p RawMouseParameters) overriddenBy(other RawMouseParameters) RawMouseParameters {
+	overrideIfSet(&p.BaseMouseSpeed, other.BaseMouseSpeed)
+	overrideIfSet(&p.StartMouseSpeed, other.StartMouseSpeed)
+	overrideIfSet(&p.MouseAccelerationCurve, other.MouseAccelerationCurve)
+	overrideIfSet(&p.MouseAccelerationTime, other.MouseAccelerationTime)
+	overrideIfSet(&p.MouseDecelerationCurve, other.MouseDecelerationCurve)
+	overrideIfSet(&p.MouseDecelerationTime, other.MouseDecelerationTime)
+	overrideIfSet(&p.BaseScrollSpeed, other.BaseScrollSpeed)
+	return p
+}
+
+// apply returns the given parameters, where all parameters that are set are replaced.
+func (p RawMouseParameters) apply(params MouseParameters) MouseParameters {
+	applyIfSet(&params.BaseMouseSpeed, p.BaseMouseSpeed)
+	applyIfSet(&params.StartMouseSpeed, p.StartMouseSpeed)
+	applyIfSet(&params.MouseAccelerationCurve, p.MouseAccelerationCurve)
+	applyIfSet(&params.MouseAccelerationTime, p.MouseAccelerationTime)
+	applyIfSet(&params.MouseDecelerationCurve, p.MouseDecelerationCurve)
+	applyIfSet(&params.MouseDecelerationTime, p.MouseDecelerationTime)
+	applyIfSet(&params.BaseScrollSpeed, p.BaseScrollSpeed)
+	return params
+}
+
+func overrideIfSet(dst **float64, src *float64) {
+	if src != nil {
+		*dst = src
+	}
+}
+
+func applyIfSet(dst *float64, src *float64) {
+	if src != nil {
+		*dst = *src
+	}
+}
+
+// sortCombos sorts the given combos by the number of keys in descending order, so that the longest combos
+// come first. Combos with the same number of keys are sorted by their key codes to get a deterministic order.
+func sortCombos(combos []*Combo) {
+	sort.Slice(combos, func(i, j int) bool {
+		if len(combos[i].Keys) != len(combos[j].Keys) {
+			return len(combos[i].Keys) > len(combos[j].Keys)
+		}
+		return slices.Compare(sortedKeys(combos[i].Keys), sortedKeys(combos[j].Keys)) < 0
+	})
+}
+
+// FindCombo returns the combo that consists of exactly the given keys (in any order), or nil if there is none.
+func FindCombo(combos []*Combo, keys []uint16) *Combo {
+	for _, combo := range combos {
+		if slices.Equal(sortedKeys(combo.Keys), sortedKeys(keys)) {
+			return combo
+		}
+	}
+	return nil
+}
+
+// sortSequences sorts the sequences by their keys, so that the order does not depend on the config file.
+func sortSequences(sequences []*Sequence) {
+	sort.Slice(sequences, func(i, j int) bool {
+		return slices.Compare(sequences[i].Keys, sequences[j].Keys) < 0
+	})
+}
+
+// FindSequence returns the sequence that consists of exactly the given keys (in this order), or nil if there is none.
+func FindSequence(sequences []*Sequence, keys []uint16) *Sequence {
+	for _, sequence := range sequences {
+		if slices.Equal(sequence.Keys, keys) {
+			return sequence
+		}
+	}
+	return nil
+}
+
+// sortedKeys returns a sorted copy of the given keys.
+func sortedKeys(keys []uint16) []uint16 {
+	sorted := slices.Clone(keys)
+	slices.Sort(sorted)
+	return sorted
+}
+
+// bindingParser parses bindings and resolves the aliases they reference.
+type bindingParser struct {
+	aliases       map[string]RawBinding
+	parsedAliases map[string]Binding
+	// the user-defined names of keys from the keyAliases section
+	keyAliases map[string]uint16
+	// the layout that is used to type the text of type actions
+	typeLayout *typeLayout
+	// the aliases that are currently being resolved, used to detect cycles
+	aliasStack []string
+}
+
+// aliasError is returned when an alias cannot be parsed.
+type aliasError struct {
+	name string
+	err  error
+}
+
+func (e *aliasError) Error() string {
+	return fmt.Sprintf("failed to parse the alias '%s%s': %v", AliasPrefix, e.name, e.err)
+}
+
+func (e *aliasError) Unwrap() error {
+	return e.err
+}
+
+func newBindingParser(aliases map[string]RawBinding, keyAliases map[string]uint16) *bindingParser {
+	return &bindingParser{
+		aliases:       aliases,
+		parsedAliases: make(map[string]Binding),
+		keyAliases:    keyAliases,
+	}
+}
+
+// parseAliases parses all aliases, so that errors are reported even for unused ones.
+func (p *bindingParser) parseAliases() error {
+	names := make([]string, 0, len(p.aliases))
+	for name := range p.aliases {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if _, err := p.parseAlias(name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parseAlias returns the parsed binding of the alias with the given name (without the AliasPrefix).
+func (p *bindingParser) parseAlias(name string) (Binding, error) {
+	if binding, ok := p.parsedAliases[name]; ok {
+		return binding, nil
+	}
+	rawBinding, ok := p.aliases[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown alias '%s%s'", AliasPrefix, name)
+	}
+	if slices.Contains(p.aliasStack, name) {
+		cycle := append(slices.Clone(p.aliasStack), name)
+		return nil, fmt.Errorf("alias cycle: %s%s", AliasPrefix, strings.Join(cycle, " -> "+AliasPrefix))
+	}
+
+	p.aliasStack = append(p.aliasStack, name)
+	binding, err := p.parseRawBinding(rawBinding)
+	p.aliasStack = p.aliasStack[:len(p.aliasStack)-1]
+	if err != nil {
+		// errors of nested aliases already contain the name of the alias that failed
+		var aliasErr *aliasError
+		if errors.As(err, &aliasErr) {
+			return nil, err
+		}
+		return nil, &PositionError{Pos: rawBinding.pos, Err: &aliasError{name: name, err: err}}
+	}
+	p.parsedAliases[name] = binding
+	return binding, nil
+}
+
 // parseBinding parses a single binding of a layer.
-func parseBinding(rawBinding string) (binding Binding, err error) {
+func (p *bindingParser) parseBinding(rawBinding string) (binding Binding, err error) {
+	rawBinding = strings.TrimSpace(rawBinding)
 	if len(rawBinding) == 0 {
 		return nil, fmt.Errorf("binding is empty")
 	}
+	if name, isAlias := strings.CutPrefix(rawBinding, AliasPrefix); isAlias {
+		return p.parseAlias(name)
+	}
 	spaceSplit := strings.Fields(rawBinding)
 	action := strings.TrimSpace(spaceSplit[0])
 	argString := strings.TrimSpace(strings.Replace(rawBinding, action, "", 1))
@@ -331,7 +1204,7 @@ func parseBinding(rawBinding string) (binding Binding, err error) {
 		}
 		multiBinding := MultiBinding{}
 		for _, arg := range metaArgs {
-			b, err := parseBinding(arg)
+			b, err := p.parseBinding(arg)
 			if err != nil {
 				return nil, err
 			}
@@ -339,35 +1212,69 @@ func parseBinding(rawBinding string) (binding Binding, err error) {
 		}
 		binding = multiBinding
 	case string(ActionTapHold):
-		tapHoldBinding, err := parseTapHoldBinding(argString)
+		tapHoldBinding, err := p.parseTapHoldBinding(argString)
 		if err != nil {
 			return nil, err
 		}
 		tapHoldBinding.TapOnNext = false
 		binding = tapHoldBinding
 	case string(ActionTapHoldNext):
-		tapHoldBinding, err := parseTapHoldBinding(argString)
+		tapHoldBinding, err := p.parseTapHoldBinding(argString)
 		if err != nil {
 			return nil, err
 		}
 		tapHoldBinding.TapOnNext = true
 		binding = tapHoldBinding
 	case string(ActionTapHoldNextRelease):
-		tapHoldBinding, err := parseTapHoldBinding(argString)
+		tapHoldBinding, err := p.parseTapHoldBinding(argString)
 		if err != nil {
 			return nil, err
 		}
 		tapHoldBinding.TapOnNextRelease = true
 		binding = tapHoldBinding
+	case string(ActionTapDance):
+		tapDanceBinding, err := p.parseTapDanceBinding(argString)
+		if err != nil {
+			return nil, err
+		}
+		binding = tapDanceBinding
+	case string(ActionLeader):
+		if len(args) != 0 {
+			return nil, fmt.Errorf("action does not take any argument")
+		}
+		binding = LeaderBinding{}
+	case string(ActionRepeat):
+		if len(args) != 0 {
+			return nil, fmt.Errorf("action does not take any argument")
+		}
+		binding = RepeatBinding{Count: 1}
+	case string(ActionRepeatCount):
+		if len(args) != 1 {
+			return nil, fmt.Errorf("action requires exactly one argument")
+		}
+		count, err := parseRepeatCount(args[0])
+		if err != nil {
+			return nil, err
+		}
+		binding = RepeatBinding{Count: count}
 	case string(ActionModLayer):
 		if len(args) != 2 {
 			return nil, fmt.Errorf("action requires exactly two arguments")
 		}
-		key, err := parseKey(args[0])
+		key, err := p.parseKey(args[0])
 		if err != nil {
 			return nil, err
 		}
 		binding = ModLayerBinding{ModKey: key, Layer: args[1]}
+	case string(ActionOneShot):
+		if len(args) != 1 {
+			return nil, fmt.Errorf("action requires exactly one argument")
+		}
+		keys, err := p.parseKeyCombo(args[0])
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse the keys '%s': %v", args[0], err)
+		}
+		binding = OneShotBinding{Keys: keys}
 	case string(ActionLayer):
 		if len(args) != 1 {
 			return nil, fmt.Errorf("action requires exactly one argument")
@@ -378,11 +1285,36 @@ func parseBinding(rawBinding string) (binding Binding, err error) {
 			return nil, fmt.Errorf("action requires exactly one argument")
 		}
 		binding = ToggleLayerBinding{Layer: args[0]}
+	case string(ActionPushLayer):
+		if len(args) != 1 {
+			return nil, fmt.Errorf("action requires exactly one argument")
+		}
+		binding = PushLayerBinding{Layer: args[0]}
+	case string(ActionOneShotLayer):
+		if len(args) != 1 {
+			return nil, fmt.Errorf("action requires exactly one argument")
+		}
+		binding = OneShotLayerBinding{Layer: args[0]}
+	case string(ActionPopLayer):
+		if len(args) != 0 {
+			return nil, fmt.Errorf("action requires zero arguments")
+		}
+		binding = PopLayerBinding{}
+	case string(ActionLayerBack):
+		if len(args) != 0 {
+			return nil, fmt.Errorf("action requires zero arguments")
+		}
+		binding = LayerBackBinding{}
 	case string(ActionReloadConfig):
 		if len(args) != 0 {
 			return nil, fmt.Errorf("action requires zero arguments")
 		}
 		binding = ReloadConfigBinding{}
+	case string(ActionStopMacro):
+		if len(args) != 0 {
+			return nil, fmt.Errorf("action requires zero arguments")
+		}
+		binding = StopMacroBinding{}
 	case string(ActionMove):
 		if len(args) != 2 {
 			return nil, fmt.Errorf("action requires exactly two arguments")
@@ -399,20 +1331,11 @@ func parseBinding(rawBinding string) (binding Binding, err error) {
 		if len(args) != 1 {
 			return nil, fmt.Errorf("action requires exactly one argument")
 		}
-		x, y := 0.0, 0.0
-		switch args[0] {
-		case "up":
-			y = -1
-		case "down":
-			y = +1
-		case "left":
-			x = -1
-		case "right":
-			x = +1
-		default:
+		scrollBinding, err := parseScrollDirection(args[0])
+		if err != nil {
 			return nil, fmt.Errorf("first argument must one of up, down, left or right")
 		}
-		binding = ScrollBinding{X: x, Y: y}
+		binding = scrollBinding
 	case string(ActionSpeed):
 		if len(args) != 1 {
 			return nil, fmt.Errorf("action requires exactly one argument")
@@ -426,9 +1349,9 @@ func parseBinding(rawBinding string) (binding Binding, err error) {
 		if len(args) != 1 {
 			return nil, fmt.Errorf("action requires exactly one argument")
 		}
-		button := MouseButton(strings.ToLower(args[0]))
-		if button != ButtonLeft && button != ButtonMiddle && button != ButtonRight {
-			return nil, fmt.Errorf("unknown button '%v'", args[0])
+		button, err := parseMouseButton(args[0])
+		if err != nil {
+			return nil, err
 		}
 		binding = ButtonBinding{Button: button}
 	case string(ActionExec):
@@ -450,8 +1373,35 @@ func parseBinding(rawBinding string) (binding Binding, err error) {
 			return nil, fmt.Errorf("action does not take any argument")
 		}
 		binding = NopBinding{}
+	case string(ActionMacro):
+		if len(args) == 0 {
+			// without steps it is the key KEY_MACRO, as it was before the action existed
+			return KeyBinding{KeyCombo: []uint16{mustGetKeyCode("macro")}}, nil
+		}
+		macroBinding := MacroBinding{}
+		for _, rawStep := range strings.Split(argString, ";") {
+			steps, err := p.parseMacroSteps(rawStep)
+			if err != nil {
+				return nil, err
+			}
+			macroBinding.Steps = append(macroBinding.Steps, steps...)
+		}
+		binding = macroBinding
+	case string(ActionType):
+		if len(args) == 0 {
+			return nil, fmt.Errorf("action requires a text")
+		}
+		text := argString
+		if strings.HasPrefix(text, `"`) {
+			if text, err = strconv.Unquote(text); err != nil {
+				return nil, fmt.Errorf("failed to parse the quoted text %s: %v", argString, err)
+			}
+		}
+		if binding, err = p.parseTypeBinding(text); err != nil {
+			return nil, err
+		}
 	default:
-		combo, err := parseKeyCombo(rawBinding)
+		combo, err := p.parseKeyCombo(rawBinding)
 		if err != nil {
 			return nil, fmt.Errorf("neither a valid action nor a valid key sequence")
 		}
@@ -461,18 +1411,72 @@ func parseBinding(rawBinding string) (binding Binding, err error) {
 	return binding, nil
 }
 
-func parseTapHoldBinding(argString string) (TapHoldBinding, error) {
+// parseTypeBinding creates a binding that types the given text.
+func (p *bindingParser) parseTypeBinding(text string) (TypeBinding, error) {
+	if text == "" {
+		return TypeBinding{}, fmt.Errorf("the text must not be empty")
+	}
+	steps, err := p.typeLayout.steps(text)
+	if err != nil {
+		return TypeBinding{}, err
+	}
+	return TypeBinding{Text: text, Steps: steps}, nil
+}
+
+// parseMacroSteps parses a step of a macro, which is one of wait <ms>, press <key-combo>, release <key-combo> or a
+// list of key combos separated by spaces, which are tapped one after another.
+func (p *bindingParser) parseMacroSteps(rawStep string) ([]MacroStep, error) {
+	fields := strings.Fields(rawStep)
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("macro step is empty")
+	}
+	switch fields[0] {
+	case "wait":
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("macro step wait requires exactly one argument")
+		}
+		waitMs, err := strconv.ParseInt(fields[1], 10, 64)
+		if err != nil || waitMs < 0 {
+			return nil, fmt.Errorf("the argument of wait must be a non-negative integer: %s", fields[1])
+		}
+		return []MacroStep{{Type: MacroStepWait, WaitMs: waitMs}}, nil
+	case "press", "release":
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("macro step %s requires exactly one argument", fields[0])
+		}
+		keys, err := p.parseKeyCombo(fields[1])
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse the keys '%s': %v", fields[1], err)
+		}
+		stepType := MacroStepPress
+		if fields[0] == "release" {
+			stepType = MacroStepRelease
+		}
+		return []MacroStep{{Type: stepType, Keys: keys}}, nil
+	}
+	var steps []MacroStep
+	for _, field := range fields {
+		keys, err := p.parseKeyCombo(field)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse the keys '%s': %v", field, err)
+		}
+		steps = append(steps, MacroStep{Type: MacroStepTap, Keys: keys})
+	}
+	return steps, nil
+}
+
+func (p *bindingParser) parseTapHoldBinding(argString string) (TapHoldBinding, error) {
 	b := TapHoldBinding{}
 	metaArgs := strings.Split(argString, ";")
 	if len(metaArgs) != 3 {
 		return b, fmt.Errorf("action requires exactly 3 meta arguments (separated by ;)")
 	}
-	b1, err := parseBinding(metaArgs[0])
+	b1, err := p.parseBinding(metaArgs[0])
 	if err != nil {
 		return b, err
 	}
 	b.TapBinding = b1
-	b2, err := parseBinding(metaArgs[1])
+	b2, err := p.parseBinding(metaArgs[1])
 	if err != nil {
 		return b, err
 	}
@@ -486,10 +1490,102 @@ func parseTapHoldBinding(argString string) (TapHoldBinding, error) {
 	return b, nil
 }
 
+// parseTapDanceBinding parses the arguments of a tap-dance binding, which are the bindings for one, two, ... taps
+// followed by the timeout. Each binding can be followed by | and a binding that is executed if the key is held after
+// that number of taps, e.g. "esc; layer mouse | toggle-layer arrows; 200", where ~ or nothing means no binding.
+func (p *bindingParser) parseTapDanceBinding(argString string) (TapDanceBinding, error) {
+	b := TapDanceBinding{}
+	metaArgs := strings.Split(argString, ";")
+	if len(metaArgs) < 2 {
+		return b, fmt.Errorf("action requires at least 2 meta arguments (separated by ;)")
+	}
+	hasHold := false
+	for _, metaArg := range metaArgs[:len(metaArgs)-1] {
+		tapArg, holdArg, isHold := strings.Cut(metaArg, "|")
+		tapBinding, err := p.parseTapDanceEntry(tapArg)
+		if err != nil {
+			return b, err
+		}
+		b.TapBindings = append(b.TapBindings, tapBinding)
+		var holdBinding Binding
+		if isHold {
+			if holdBinding, err = p.parseTapDanceEntry(holdArg); err != nil {
+				return b, err
+			}
+		}
+		b.HoldBindings = append(b.HoldBindings, holdBinding)
+		hasHold = hasHold || holdBinding != nil
+	}
+	if !hasHold {
+		b.HoldBindings = nil
+	}
+	if b.MaxTaps() < 2 && !hasHold {
+		return b, fmt.Errorf("action requires at least two taps or a hold binding")
+	}
+	timeoutStr := strings.TrimSpace(metaArgs[len(metaArgs)-1])
+	timeout, err := strconv.ParseInt(timeoutStr, 10, 64)
+	if err != nil || timeout <= 0 {
+		return b, fmt.Errorf("last argument must be a positive number: %s", timeoutStr)
+	}
+	b.TimeoutMs = timeout
+	return b, nil
+}
+
+// parseTapDanceEntry parses a tap or hold binding of a tap-dance, which is nil if it is empty or transparent.
+func (p *bindingParser) parseTapDanceEntry(arg string) (Binding, error) {
+	if arg = strings.TrimSpace(arg); arg == "" || arg == TransparentMarker {
+		return nil, nil
+	}
+	return p.parseBinding(arg)
+}
+
+// parseScrollDirection parses the direction of a scroll action, which is one of up, down, left or right.
+func parseScrollDirection(direction string) (ScrollBinding, error) {
+	switch direction {
+	case "up":
+		return ScrollBinding{Y: -1}, nil
+	case "down":
+		return ScrollBinding{Y: +1}, nil
+	case "left":
+		return ScrollBinding{X: -1}, nil
+	case "right":
+		return ScrollBinding{X: +1}, nil
+	}
+	return ScrollBinding{}, fmt.Errorf("unknown scroll direction '%v'", direction)
+}
+
+// parseMouseButton parses the name of a mouse button.
+func parseMouseButton(name string) (MouseButton, error) {
+	button := MouseButton(strings.ToLower(name))
+	if _, ok := mouseButtonCodes[button]; !ok {
+		return "", fmt.Errorf("unknown button '%v'", name)
+	}
+	return button, nil
+}
+
+// parseKeyAliases parses the user-defined key aliases, which map a new name to a key name or code.
+func parseKeyAliases(rawKeyAliases map[string]string) (map[string]uint16, error) {
+	keyAliases := make(map[string]uint16)
+	for name, key := range rawKeyAliases {
+		if name == "" || strings.ContainsAny(name, "+ \t") {
+			return nil, fmt.Errorf("invalid key alias '%s': must not be empty or contain '+' or spaces", name)
+		}
+		if _, err := parseKey(name); err == nil {
+			return nil, fmt.Errorf("invalid key alias '%s': it is already the name of a key", name)
+		}
+		code, err := parseKey(key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse the key '%s' of the key alias '%s': %v", key, name, err)
+		}
+		keyAliases[name] = code
+	}
+	return keyAliases, nil
+}
+
 // parseKeyCombo parses a key combination of the form key1+key2+...
-func parseKeyCombo(rawCombo string) (combo []uint16, err error) {
+func (p *bindingParser) parseKeyCombo(rawCombo string) (combo []uint16, err error) {
 	for _, key := range strings.Split(rawCombo, "+") {
-		code, err := parseKey(key)
+		code, err := p.parseKey(key)
 		if err != nil {
 			return combo, err
 		}
@@ -498,11 +1594,43 @@ func parseKeyCombo(rawCombo string) (combo []uint16, err error) {
 	return combo, nil
 }
 
+// parseSequence parses the keys of a sequence binding, e.g. j>k.
+func (p *bindingParser) parseSequence(rawSequence string) (sequence []uint16, err error) {
+	for _, key := range strings.Split(rawSequence, SequenceSeparator) {
+		code, err := p.parseKey(strings.TrimSpace(key))
+		if err != nil {
+			return nil, err
+		}
+		if code == WildcardKey {
+			return nil, fmt.Errorf("the wildcard key cannot be part of a sequence")
+		}
+		sequence = append(sequence, code)
+	}
+	return sequence, nil
+}
+
+// parseRepeatCount parses the number of repetitions of a repeat-count binding.
+func parseRepeatCount(rawCount string) (int, error) {
+	count, err := strconv.Atoi(strings.TrimSpace(rawCount))
+	if err != nil || count < 1 {
+		return 0, fmt.Errorf("the count must be a positive integer")
+	}
+	return count, nil
+}
+
+// parseKey parses a single key, which can be either the code itself, an alias or a user-defined key alias.
+func (p *bindingParser) parseKey(key string) (code uint16, err error) {
+	if code, ok := p.keyAliases[strings.TrimSpace(key)]; ok {
+		return code, nil
+	}
+	return parseKey(key)
+}
+
 // parseKey parses a single key, which can be either the code itself or an alias.
 func parseKey(key string) (code uint16, err error) {
 	key = strings.TrimSpace(key)
 
-	if code, ok := keyAliases[key]; ok {
+	if code, ok := GetKeyCode(key); ok {
 		return code, nil
 	}
 
@@ -0,0 +1,310 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// parseTestConfig parses the given config and fails the test on errors.
+func parseTestConfig(t *testing.T, configString string) *Config {
+	t.Helper()
+	conf, err := ParseConfig([]byte(configString))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return conf
+}
+
+// assertParseError checks that parsing the given config fails with an error containing the expected message.
+func assertParseError(t *testing.T, configString string, expected string) {
+	t.Helper()
+	_, err := ParseConfig([]byte(configString))
+	if err == nil {
+		t.Errorf("expected an error containing '%s'", expected)
+	} else if !strings.Contains(err.Error(), expected) {
+		t.Errorf("expected an error containing '%s' but got '%v'", expected, err)
+	}
+}
+
+// assertBinding checks that the given binding matches the expected one.
+func assertBinding(t *testing.T, binding Binding, expected Binding) {
+	t.Helper()
+	if !reflect.DeepEqual(binding, expected) {
+		t.Errorf("expected the binding %#v but got %#v", expected, binding)
+	}
+}
+
+func TestTapDanceString(t *testing.T) {
+	conf := parseTestConfig(t, `
+layers:
+- name: initial
+  bindings:
+    a: tap-dance esc; layer mouse | toggle-layer arrows; 200
+    b: tap-dance ~ | toggle-layer arrows; 200
+    c: tap-dance x; y; z; 150
+`)
+	bindings := conf.Layers[0].Bindings
+	assertBinding(t, bindings[30], TapDanceBinding{
+		TapBindings:  []Binding{KeyBinding{KeyCombo: []uint16{1}}, LayerBinding{Layer: "mouse"}},
+		HoldBindings: []Binding{nil, ToggleLayerBinding{Layer: "arrows"}},
+		TimeoutMs:    200,
+	})
+	assertBinding(t, bindings[48], TapDanceBinding{
+		TapBindings:  []Binding{nil},
+		HoldBindings: []Binding{ToggleLayerBinding{Layer: "arrows"}},
+		TimeoutMs:    200,
+	})
+	assertBinding(t, bindings[46], TapDanceBinding{
+		TapBindings: []Binding{KeyBinding{KeyCombo: []uint16{45}}, KeyBinding{KeyCombo: []uint16{21}},
+			KeyBinding{KeyCombo: []uint16{44}}},
+		TimeoutMs: 150,
+	})
+
+	assertParseError(t, `
+layers:
+- name: initial
+  bindings:
+    a: tap-dance esc; 200
+`, "action requires at least two taps or a hold binding")
+	assertParseError(t, `
+layers:
+- name: initial
+  bindings:
+    a: tap-dance esc; y | foo bar; 200
+`, "failed to parse the binding 'tap-dance esc; y | foo bar; 200'")
+}
+
+func TestAliases(t *testing.T) {
+	conf := parseTestConfig(t, `
+aliases:
+  mouse: toggle-layer mouse
+  mouse_tab: tap-hold-next tab; @mouse; 500
+  both: "@mouse_tab"
+layers:
+- name: initial
+  bindings:
+    a: "@mouse"
+    b: multi @mouse; x
+    c: "@both"
+`)
+	bindings := conf.Layers[0].Bindings
+	assertBinding(t, bindings[30], ToggleLayerBinding{Layer: "mouse"})
+	assertBinding(t, bindings[48], MultiBinding{Bindings: []Binding{
+		ToggleLayerBinding{Layer: "mouse"},
+		KeyBinding{KeyCombo: []uint16{45}},
+	}})
+	assertBinding(t, bindings[46], TapHoldBinding{
+		TapBinding:  KeyBinding{KeyCombo: []uint16{15}},
+		HoldBinding: ToggleLayerBinding{Layer: "mouse"},
+		TimeoutMs:   500,
+		TapOnNext:   true,
+	})
+
+	assertParseError(t, `
+layers:
+- name: initial
+  bindings:
+    a: "@unknown"
+`, "unknown alias '@unknown'")
+	assertParseError(t, `
+aliases:
+  unused: foo bar
+layers:
+- name: initial
+  bindings:
+    a: b
+`, "failed to parse the alias '@unused'")
+	assertParseError(t, `
+aliases:
+  a: multi x; @b
+  b: "@c"
+  c: tap-hold x; @a; 200
+layers:
+- name: initial
+  bindings:
+    a: "@a"
+`, "alias cycle: @a -> @b -> @c -> @a")
+	assertParseError(t, `
+aliases:
+  self: "@self"
+layers:
+- name: initial
+`, "alias cycle: @self -> @self")
+}
+
+func TestMouseButtons(t *testing.T) {
+	conf := parseTestConfig(t, `
+layers:
+- name: initial
+  bindings:
+    a: button side
+    b: button Extra
+    c: {action: button, button: task}
+`)
+	bindings := conf.Layers[0].Bindings
+	assertBinding(t, bindings[30], ButtonBinding{Button: ButtonSide})
+	assertBinding(t, bindings[48], ButtonBinding{Button: ButtonExtra})
+	assertBinding(t, bindings[46], ButtonBinding{Button: ButtonTask})
+
+	// the buttons are named after BTN_LEFT to BTN_TASK of input-event-codes.h
+	names := []MouseButton{"left", "right", "middle", "side", "extra", "forward", "back", "task"}
+	buttons := MouseButtons()
+	if len(buttons) != len(names) {
+		t.Fatalf("expected the buttons %v but got %v", names, buttons)
+	}
+	for i, name := range names {
+		if buttons[i] != name || buttons[i].Code() != uint16(0x110+i) {
+			t.Errorf("expected the button %s with the code %#x but got %s with %#x", name, 0x110+i, buttons[i],
+				buttons[i].Code())
+		}
+	}
+
+	assertParseError(t, `
+layers:
+- name: initial
+  bindings:
+    a: button wheel
+`, "unknown button 'wheel'")
+}
+
+func TestMacroKey(t *testing.T) {
+	conf := parseTestConfig(t, `
+layers:
+- name: initial
+  bindings:
+    a: macro
+    b: macro a; wait 10
+    c: leftctrl+macro
+`)
+	bindings := conf.Layers[0].Bindings
+	assertBinding(t, bindings[30], KeyBinding{KeyCombo: []uint16{112}})
+	assertBinding(t, bindings[48], MacroBinding{Steps: []MacroStep{
+		{Type: MacroStepTap, Keys: []uint16{30}},
+		{Type: MacroStepWait, WaitMs: 10},
+	}})
+	assertBinding(t, bindings[46], KeyBinding{KeyCombo: []uint16{29, 112}})
+}
+
+func TestTransparentBindings(t *testing.T) {
+	conf := parseTestConfig(t, `
+layers:
+- name: initial
+  bindings:
+    a: ~
+    b: null
+    c: "~"
+`)
+	bindings := conf.Layers[0].Bindings
+	assertBinding(t, bindings[30], TransparentBinding{})
+	assertBinding(t, bindings[48], TransparentBinding{})
+	assertBinding(t, bindings[46], TransparentBinding{})
+
+	// an empty binding is more likely a mistake
+	assertParseError(t, `
+layers:
+- name: initial
+  bindings:
+    a:
+`, "failed to parse the binding '': binding is empty")
+	assertParseError(t, `
+layers:
+- name: initial
+  bindings:
+    a: ""
+`, "binding is empty")
+}
+
+func TestProfiles(t *testing.T) {
+	conf := parseTestConfig(t, `
+profiles:
+- name: split
+  devices: [Moonlander]
+  initialLayer: nav
+- name: laptop
+  devices: [AT Keyboard, Moonlander]
+layers:
+- name: initial
+- name: nav
+`)
+	if len(conf.Profiles) != 2 {
+		t.Fatalf("expected 2 profiles but got %d", len(conf.Profiles))
+	}
+	if layer := conf.Profiles[0].InitialLayer; layer != conf.Layers[1] {
+		t.Errorf("expected the initial layer nav but got %s", layer.Name)
+	}
+	// the initial layer defaults to the first layer
+	if layer := conf.Profiles[1].InitialLayer; layer != conf.Layers[0] {
+		t.Errorf("expected the first layer as initial layer but got %s", layer.Name)
+	}
+
+	tests := []struct {
+		profiles string
+		expected string
+	}{
+		{"[{name: split, devices: [a], initialLayer: mouse}]", "profile 'split' has the unknown initial layer 'mouse'"},
+		{"[{name: split}]", "profile 'split' has no devices"},
+		{"[{devices: [a]}]", "profile 0 has no name"},
+		{"[{name: split, devices: [a]}, {name: split, devices: [b]}]", "duplicate profile 'split'"},
+	}
+	for _, test := range tests {
+		assertParseError(t, `
+profiles: `+test.profiles+`
+layers:
+- name: initial
+`, test.expected)
+	}
+}
+
+func TestLayerMouseParameters(t *testing.T) {
+	conf := parseTestConfig(t, `
+baseMouseSpeed: 750
+mouseAccelerationTime: 200
+baseScrollSpeed: 20
+layers:
+- name: initial
+- name: mouse
+  baseMouseSpeed: 300
+  mouseAccelerationTime: 0
+- name: precise
+  extends: mouse
+  baseMouseSpeed: 100
+  startMouseSpeed: 50
+- name: other
+  extends: precise
+  baseScrollSpeed: 5
+`)
+	global := MouseParameters{
+		BaseMouseSpeed:         750,
+		MouseAccelerationCurve: 1,
+		MouseAccelerationTime:  200,
+		MouseDecelerationCurve: 1,
+		BaseScrollSpeed:        20,
+	}
+	expected := map[string]MouseParameters{"initial": global}
+	// only the options that are set are overridden
+	expected["mouse"] = MouseParameters{BaseMouseSpeed: 300, MouseAccelerationCurve: 1, MouseDecelerationCurve: 1,
+		BaseScrollSpeed: 20}
+	// the overrides are inherited with extends, where the own ones take precedence
+	expected["precise"] = MouseParameters{BaseMouseSpeed: 100, StartMouseSpeed: 50, MouseAccelerationCurve: 1,
+		MouseDecelerationCurve: 1, BaseScrollSpeed: 20}
+	expected["other"] = MouseParameters{BaseMouseSpeed: 100, StartMouseSpeed: 50, MouseAccelerationCurve: 1,
+		MouseDecelerationCurve: 1, BaseScrollSpeed: 5}
+	for _, layer := range conf.Layers {
+		if layer.MouseParameters != expected[layer.Name] {
+			t.Errorf("expected the mouse parameters %+v for the layer %s but got %+v", expected[layer.Name],
+				layer.Name, layer.MouseParameters)
+		}
+	}
+
+	assertParseError(t, `
+layers:
+- name: initial
+  baseMouseSpeed: -1
+`, "layer 'initial': the mouse speeds and times must not be negative")
+	assertParseError(t, `
+layers:
+- name: initial
+  mouseDecelerationCurve: 0
+`, "layer 'initial': the mouse acceleration and deceleration curves must be positive")
+}
@@ -0,0 +1,195 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// readRawConfig reads the given config file and merges it with all the files it includes.
+// The includes are resolved relative to the including file and are merged in the given order,
+// the including file itself is merged last, so that it can override anything from its includes.
+// includeStack contains the files that are currently being read, which is used to detect include cycles.
+func readRawConfig(fileName string, includeStack []string) (*RawConfig, error) {
+	absFileName, err := filepath.Abs(fileName)
+	if err != nil {
+		return nil, err
+	}
+	for i, f := range includeStack {
+		if f == absFileName {
+			cycle := append(includeStack[i:], absFileName)
+			return nil, fmt.Errorf("include cycle detected: %s", strings.Join(cycle, " -> "))
+		}
+	}
+	includeStack = append(includeStack, absFileName)
+
+	configBytes, err := os.ReadFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+	rawConfig, err := unmarshalRawConfig(configBytes, fileName)
+	if err != nil {
+		return nil, err
+	}
+	rawConfig.files = []string{absFileName}
+
+	merged := &RawConfig{}
+	for _, include := range rawConfig.Include {
+		includeFile := include
+		if !filepath.IsAbs(includeFile) {
+			includeFile = filepath.Join(filepath.Dir(absFileName), includeFile)
+		}
+		included, err := readRawConfig(includeFile, includeStack)
+		if err != nil {
+			return nil, fmt.Errorf("failed to include '%s' from %s: %w", include, fileName, err)
+		}
+		mergeRawConfig(merged, included)
+	}
+	mergeRawConfig(merged, rawConfig)
+
+	return merged, nil
+}
+
+// mergeRawConfig merges src into dst, where all options that are set in src override the ones in dst.
+// Layers are merged by name, i.e. the bindings of a layer that exists in both are merged, and layers that
+// only exist in src are appended.
+func mergeRawConfig(dst *RawConfig, src *RawConfig) {
+	for _, file := range src.files {
+		if !slices.Contains(dst.files, file) {
+			dst.files = append(dst.files, file)
+		}
+	}
+	if src.Devices != nil {
+		dst.Devices = src.Devices
+	}
+	if src.DevicesExclude != nil {
+		dst.DevicesExclude = src.DevicesExclude
+	}
+	if src.Profiles != nil {
+		dst.Profiles = src.Profiles
+	}
+	if src.StartCommand != "" {
+		dst.StartCommand = src.StartCommand
+	}
+	if src.MouseLoopInterval != 0 {
+		dst.MouseLoopInterval = src.MouseLoopInterval
+	}
+	if src.BaseMouseSpeed != 0 {
+		dst.BaseMouseSpeed = src.BaseMouseSpeed
+	}
+	if src.StartMouseSpeed != 0 {
+		dst.StartMouseSpeed = src.StartMouseSpeed
+	}
+	if src.MouseAccelerationCurve != 0 {
+		dst.MouseAccelerationCurve = src.MouseAccelerationCurve
+	}
+	if src.MouseAccelerationTime != 0 {
+		dst.MouseAccelerationTime = src.MouseAccelerationTime
+	}
+	if src.MouseDecelerationCurve != 0 {
+		dst.MouseDecelerationCurve = src.MouseDecelerationCurve
+	}
+	if src.MouseDecelerationTime != 0 {
+		dst.MouseDecelerationTime = src.MouseDecelerationTime
+	}
+	if src.BaseScrollSpeed != 0 {
+		dst.BaseScrollSpeed = src.BaseScrollSpeed
+	}
+	if src.QuickTapTime != 0 {
+		dst.QuickTapTime = src.QuickTapTime
+	}
+	if src.ComboTime != 0 {
+		dst.ComboTime = src.ComboTime
+	}
+	if src.SequenceTime != 0 {
+		dst.SequenceTime = src.SequenceTime
+	}
+	if src.OneShotTimeout != 0 {
+		dst.OneShotTimeout = src.OneShotTimeout
+	}
+	if src.OneShotCancelOnEscape != nil {
+		dst.OneShotCancelOnEscape = src.OneShotCancelOnEscape
+	}
+	if src.LeaderTimeout != 0 {
+		dst.LeaderTimeout = src.LeaderTimeout
+	}
+	if src.LeaderReplayUnknown != nil {
+		dst.LeaderReplayUnknown = src.LeaderReplayUnknown
+	}
+	if src.RepeatDelay != 0 {
+		dst.RepeatDelay = src.RepeatDelay
+	}
+	if src.RepeatRate != 0 {
+		dst.RepeatRate = src.RepeatRate
+	}
+	dst.LeaderSequences = mergeMaps(dst.LeaderSequences, src.LeaderSequences)
+	if src.InstanceName != "" {
+		dst.InstanceName = src.InstanceName
+	}
+	dst.Aliases = mergeMaps(dst.Aliases, src.Aliases)
+	dst.KeyAliases = mergeMaps(dst.KeyAliases, src.KeyAliases)
+	if src.TypeLayout != "" {
+		dst.TypeLayout = src.TypeLayout
+	}
+	dst.TypeLayoutKeys = mergeMaps(dst.TypeLayoutKeys, src.TypeLayoutKeys)
+	if src.TypeUnicodeInput != "" {
+		dst.TypeUnicodeInput = src.TypeUnicodeInput
+	}
+
+	for _, srcLayer := range src.Layers {
+		merged := false
+		for i := range dst.Layers {
+			if dst.Layers[i].Name == srcLayer.Name {
+				mergeRawLayer(&dst.Layers[i], srcLayer)
+				merged = true
+				break
+			}
+		}
+		if !merged {
+			dst.Layers = append(dst.Layers, srcLayer)
+		}
+	}
+}
+
+// mergeRawLayer merges src into dst, where all options and bindings that are set in src override the ones in dst.
+func mergeRawLayer(dst *RawLayer, src RawLayer) {
+	if src.PassThrough != nil {
+		dst.PassThrough = src.PassThrough
+	}
+	if src.EnterCommand != nil {
+		dst.EnterCommand = src.EnterCommand
+	}
+	if src.ExitCommand != nil {
+		dst.ExitCommand = src.ExitCommand
+	}
+	if src.Extends != "" {
+		dst.Extends = src.Extends
+	}
+	if src.ComboTime != 0 {
+		dst.ComboTime = src.ComboTime
+	}
+	dst.ComboTimes = mergeMaps(dst.ComboTimes, src.ComboTimes)
+	if src.Repeat != nil {
+		dst.Repeat = src.Repeat
+	}
+	dst.RepeatKeys = append(dst.RepeatKeys, src.RepeatKeys...)
+	dst.RawMouseParameters = dst.RawMouseParameters.overriddenBy(src.RawMouseParameters)
+	dst.Bindings = mergeMaps(dst.Bindings, src.Bindings)
+}
+
+// mergeMaps returns a new map with all entries of dst and src, where the ones of src take precedence.
+func mergeMaps[V any](dst map[string]V, src map[string]V) map[string]V {
+	if dst == nil && src == nil {
+		return nil
+	}
+	merged := make(map[string]V, len(dst)+len(src))
+	for k, v := range dst {
+		merged[k] = v
+	}
+	for k, v := range src {
+		merged[k] = v
+	}
+	return merged
+}
@@ -0,0 +1,156 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeConfigFiles writes the given files to a temporary directory and returns its path.
+func writeConfigFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestIncludeExtends(t *testing.T) {
+	dir := writeConfigFiles(t, map[string]string{
+		"base.yaml": `
+layers:
+- name: initial
+  bindings:
+    a: b
+- name: nav
+  bindings:
+    h: left
+`,
+		"config.yaml": `
+include: [base.yaml]
+layers:
+- name: nav
+  extends: initial
+`,
+	})
+	conf, err := ReadConfig(filepath.Join(dir, "config.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	nav := conf.Layers[1]
+	if nav.Extends != "initial" {
+		t.Errorf("expected the layer to extend 'initial' but got '%s'", nav.Extends)
+	}
+	if _, ok := nav.Bindings[30]; !ok {
+		t.Errorf("expected the binding of a to be inherited")
+	}
+	if _, ok := nav.Bindings[35]; !ok {
+		t.Errorf("expected the binding of h to be kept")
+	}
+}
+
+func TestIncludeOverride(t *testing.T) {
+	dir := writeConfigFiles(t, map[string]string{
+		"base.yaml": `
+comboTime: 30
+layers:
+- name: initial
+  bindings:
+    a: b
+    c: d
+`,
+		"config.yaml": `
+include: [base.yaml]
+layers:
+- name: initial
+  bindings:
+    a: x
+- name: mouse
+  bindings:
+    h: left
+`,
+	})
+	conf, err := ReadConfig(filepath.Join(dir, "config.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if conf.ComboTime != 30 {
+		t.Errorf("expected the comboTime of the included file but got %v", conf.ComboTime)
+	}
+	if len(conf.Layers) != 2 || conf.Layers[1].Name != "mouse" {
+		t.Fatalf("expected the layers initial and mouse but got %d layers", len(conf.Layers))
+	}
+	assertBinding(t, conf.Layers[0].Bindings[30], KeyBinding{KeyCombo: []uint16{45}})
+	assertBinding(t, conf.Layers[0].Bindings[46], KeyBinding{KeyCombo: []uint16{32}})
+}
+
+func TestIncludeCycle(t *testing.T) {
+	dir := writeConfigFiles(t, map[string]string{
+		"a.yaml": `
+include: [b.yaml]
+`,
+		"b.yaml": `
+include: [a.yaml]
+layers:
+- name: initial
+`,
+	})
+	_, err := ReadConfig(filepath.Join(dir, "a.yaml"))
+	if err == nil {
+		t.Fatal("expected an error for the include cycle")
+	}
+	expected := "include cycle detected: " + filepath.Join(dir, "a.yaml") + " -> " + filepath.Join(dir, "b.yaml") +
+		" -> " + filepath.Join(dir, "a.yaml")
+	if !strings.Contains(err.Error(), expected) {
+		t.Errorf("expected an error containing '%s' but got '%v'", expected, err)
+	}
+
+	dir = writeConfigFiles(t, map[string]string{
+		"config.yaml": `
+include: [config.yaml]
+`,
+	})
+	if _, err := ReadConfig(filepath.Join(dir, "config.yaml")); err == nil {
+		t.Error("expected an error for a file that includes itself")
+	}
+}
+
+func TestDuplicateLayers(t *testing.T) {
+	dir := writeConfigFiles(t, map[string]string{
+		"config.yaml": `
+layers:
+- name: initial
+  bindings:
+    a: b
+- name: nav
+- name: initial
+  bindings:
+    c: d
+`,
+	})
+	_, err := ReadConfig(filepath.Join(dir, "config.yaml"))
+	expected := filepath.Join(dir, "config.yaml") + ":7:3: the layer 'initial' is already defined at line 3"
+	if err == nil || err.Error() != expected {
+		t.Errorf("expected the error '%s' but got '%v'", expected, err)
+	}
+
+	// the same layer in different files is merged
+	dir = writeConfigFiles(t, map[string]string{
+		"base.yaml": `
+layers:
+- name: initial
+`,
+		"config.yaml": `
+include: [base.yaml]
+layers:
+- name: initial
+`,
+	})
+	if _, err := ReadConfig(filepath.Join(dir, "config.yaml")); err != nil {
+		t.Errorf("expected no error for a layer in different files but got '%v'", err)
+	}
+}
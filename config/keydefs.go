@@ -2,235 +2,650 @@ package config
 
 import (
 	"slices"
+	"strings"
 )
 
 const WildcardKey = 10000
 
 var keyAliases = map[string]uint16{
-	"_":                WildcardKey,
-	"reserved":         0,
-	"esc":              1,
-	"k1":               2,
-	"k2":               3,
-	"k3":               4,
-	"k4":               5,
-	"k5":               6,
-	"k6":               7,
-	"k7":               8,
-	"k8":               9,
-	"k9":               10,
-	"k0":               11,
-	"minus":            12,
-	"equal":            13,
-	"backspace":        14,
-	"tab":              15,
-	"q":                16,
-	"w":                17,
-	"e":                18,
-	"r":                19,
-	"t":                20,
-	"y":                21,
-	"u":                22,
-	"i":                23,
-	"o":                24,
-	"p":                25,
-	"leftbrace":        26,
-	"rightbrace":       27,
-	"enter":            28,
-	"leftctrl":         29,
-	"a":                30,
-	"s":                31,
-	"d":                32,
-	"f":                33,
-	"g":                34,
-	"h":                35,
-	"j":                36,
-	"k":                37,
-	"l":                38,
-	"semicolon":        39,
-	"apostrophe":       40,
-	"grave":            41,
-	"leftshift":        42,
-	"backslash":        43,
-	"z":                44,
-	"x":                45,
-	"c":                46,
-	"v":                47,
-	"b":                48,
-	"n":                49,
-	"m":                50,
-	"comma":            51,
-	"dot":              52,
-	"slash":            53,
-	"rightshift":       54,
-	"kpasterisk":       55,
-	"leftalt":          56,
-	"space":            57,
-	"capslock":         58,
-	"f1":               59,
-	"f2":               60,
-	"f3":               61,
-	"f4":               62,
-	"f5":               63,
-	"f6":               64,
-	"f7":               65,
-	"f8":               66,
-	"f9":               67,
-	"f10":              68,
-	"numlock":          69,
-	"scrolllock":       70,
-	"kp7":              71,
-	"kp8":              72,
-	"kp9":              73,
-	"kpminus":          74,
-	"kp4":              75,
-	"kp5":              76,
-	"kp6":              77,
-	"kpplus":           78,
-	"kp1":              79,
-	"kp2":              80,
-	"kp3":              81,
-	"kp0":              82,
-	"kpdot":            83,
-	"zenkakuhankaku":   85,
-	"102nd":            86,
-	"f11":              87,
-	"f12":              88,
-	"ro":               89,
-	"katakana":         90,
-	"hiragana":         91,
-	"henkan":           92,
-	"katakanahiragana": 93,
-	"muhenkan":         94,
-	"kpjpcomma":        95,
-	"kpenter":          96,
-	"rightctrl":        97,
-	"kpslash":          98,
-	"sysrq":            99,
-	"rightalt":         100,
-	"linefeed":         101,
-	"home":             102,
-	"up":               103,
-	"pageup":           104,
-	"left":             105,
-	"right":            106,
-	"end":              107,
-	"down":             108,
-	"pagedown":         109,
-	"insert":           110,
-	"delete":           111,
-	"macro":            112,
-	"mute":             113,
-	"volumedown":       114,
-	"volumeup":         115,
-	"power":            116,
-	"kpequal":          117,
-	"kpplusminus":      118,
-	"pause":            119,
-	"scale":            120,
-	"kpcomma":          121,
-	"hangeul":          122,
-	"hanguel":          122,
-	"hanja":            123,
-	"yen":              124,
-	"leftmeta":         125,
-	"rightmeta":        126,
-	"compose":          127,
-	"stop":             128,
-	"again":            129,
-	"props":            130,
-	"undo":             131,
-	"front":            132,
-	"copy":             133,
-	"open":             134,
-	"paste":            135,
-	"find":             136,
-	"cut":              137,
-	"help":             138,
-	"menu":             139,
-	"calc":             140,
-	"setup":            141,
-	"sleep":            142,
-	"wakeup":           143,
-	"file":             144,
-	"sendfile":         145,
-	"deletefile":       146,
-	"xfer":             147,
-	"prog1":            148,
-	"prog2":            149,
-	"www":              150,
-	"msdos":            151,
-	"coffee":           152,
-	"screenlock":       152,
-	"rotate_display":   153,
-	"direction":        153,
-	"cyclewindows":     154,
-	"mail":             155,
-	"bookmarks":        156,
-	"computer":         157,
-	"back":             158,
-	"forward":          159,
-	"closecd":          160,
-	"ejectcd":          161,
-	"ejectclosecd":     162,
-	"nextsong":         163,
-	"playpause":        164,
-	"previoussong":     165,
-	"stopcd":           166,
-	"record":           167,
-	"rewind":           168,
-	"phone":            169,
-	"iso":              170,
-	"config":           171,
-	"homepage":         172,
-	"refresh":          173,
-	"exit":             174,
-	"move":             175,
-	"edit":             176,
-	"scrollup":         177,
-	"scrolldown":       178,
-	"kpleftparen":      179,
-	"kprightparen":     180,
-	"new":              181,
-	"redo":             182,
-	"f13":              183,
-	"f14":              184,
-	"f15":              185,
-	"f16":              186,
-	"f17":              187,
-	"f18":              188,
-	"f19":              189,
-	"f20":              190,
-	"f21":              191,
-	"f22":              192,
-	"f23":              193,
-	"f24":              194,
-	"playcd":           200,
-	"pausecd":          201,
-	"prog3":            202,
-	"prog4":            203,
-	"dashboard":        204,
-	"suspend":          205,
-	"close":            206,
-	"play":             207,
-	"fastforward":      208,
-	"bassboost":        209,
-	"print":            210,
-	"hp":               211,
-	"camera":           212,
-	"sound":            213,
-	"question":         214,
-	"email":            215,
-	"chat":             216,
-	"search":           217,
-	"connect":          218,
-	"finance":          219,
-	"sport":            220,
-	"shop":             221,
-	"alterase":         222,
-	"cancel":           223,
-	"brightnessdown":   224,
-	"brightnessup":     225,
+	"_":                        WildcardKey,
+	"reserved":                 0,
+	"esc":                      1,
+	"k1":                       2,
+	"k2":                       3,
+	"k3":                       4,
+	"k4":                       5,
+	"k5":                       6,
+	"k6":                       7,
+	"k7":                       8,
+	"k8":                       9,
+	"k9":                       10,
+	"k0":                       11,
+	"minus":                    12,
+	"equal":                    13,
+	"backspace":                14,
+	"tab":                      15,
+	"q":                        16,
+	"w":                        17,
+	"e":                        18,
+	"r":                        19,
+	"t":                        20,
+	"y":                        21,
+	"u":                        22,
+	"i":                        23,
+	"o":                        24,
+	"p":                        25,
+	"leftbrace":                26,
+	"rightbrace":               27,
+	"enter":                    28,
+	"leftctrl":                 29,
+	"a":                        30,
+	"s":                        31,
+	"d":                        32,
+	"f":                        33,
+	"g":                        34,
+	"h":                        35,
+	"j":                        36,
+	"k":                        37,
+	"l":                        38,
+	"semicolon":                39,
+	"apostrophe":               40,
+	"grave":                    41,
+	"leftshift":                42,
+	"backslash":                43,
+	"z":                        44,
+	"x":                        45,
+	"c":                        46,
+	"v":                        47,
+	"b":                        48,
+	"n":                        49,
+	"m":                        50,
+	"comma":                    51,
+	"dot":                      52,
+	"slash":                    53,
+	"rightshift":               54,
+	"kpasterisk":               55,
+	"leftalt":                  56,
+	"space":                    57,
+	"capslock":                 58,
+	"f1":                       59,
+	"f2":                       60,
+	"f3":                       61,
+	"f4":                       62,
+	"f5":                       63,
+	"f6":                       64,
+	"f7":                       65,
+	"f8":                       66,
+	"f9":                       67,
+	"f10":                      68,
+	"numlock":                  69,
+	"scrolllock":               70,
+	"kp7":                      71,
+	"kp8":                      72,
+	"kp9":                      73,
+	"kpminus":                  74,
+	"kp4":                      75,
+	"kp5":                      76,
+	"kp6":                      77,
+	"kpplus":                   78,
+	"kp1":                      79,
+	"kp2":                      80,
+	"kp3":                      81,
+	"kp0":                      82,
+	"kpdot":                    83,
+	"zenkakuhankaku":           85,
+	"102nd":                    86,
+	"f11":                      87,
+	"f12":                      88,
+	"ro":                       89,
+	"katakana":                 90,
+	"hiragana":                 91,
+	"henkan":                   92,
+	"katakanahiragana":         93,
+	"muhenkan":                 94,
+	"kpjpcomma":                95,
+	"kpenter":                  96,
+	"rightctrl":                97,
+	"kpslash":                  98,
+	"sysrq":                    99,
+	"rightalt":                 100,
+	"linefeed":                 101,
+	"home":                     102,
+	"up":                       103,
+	"pageup":                   104,
+	"left":                     105,
+	"right":                    106,
+	"end":                      107,
+	"down":                     108,
+	"pagedown":                 109,
+	"insert":                   110,
+	"delete":                   111,
+	"macro":                    112,
+	"mute":                     113,
+	"volumedown":               114,
+	"volumeup":                 115,
+	"power":                    116,
+	"kpequal":                  117,
+	"kpplusminus":              118,
+	"pause":                    119,
+	"scale":                    120,
+	"kpcomma":                  121,
+	"hangeul":                  122,
+	"hanja":                    123,
+	"yen":                      124,
+	"leftmeta":                 125,
+	"rightmeta":                126,
+	"compose":                  127,
+	"stop":                     128,
+	"again":                    129,
+	"props":                    130,
+	"undo":                     131,
+	"front":                    132,
+	"copy":                     133,
+	"open":                     134,
+	"paste":                    135,
+	"find":                     136,
+	"cut":                      137,
+	"help":                     138,
+	"menu":                     139,
+	"calc":                     140,
+	"setup":                    141,
+	"sleep":                    142,
+	"wakeup":                   143,
+	"file":                     144,
+	"sendfile":                 145,
+	"deletefile":               146,
+	"xfer":                     147,
+	"prog1":                    148,
+	"prog2":                    149,
+	"www":                      150,
+	"msdos":                    151,
+	"screenlock":               152,
+	"rotate_display":           153,
+	"cyclewindows":             154,
+	"mail":                     155,
+	"bookmarks":                156,
+	"computer":                 157,
+	"back":                     158,
+	"forward":                  159,
+	"closecd":                  160,
+	"ejectcd":                  161,
+	"ejectclosecd":             162,
+	"nextsong":                 163,
+	"playpause":                164,
+	"previoussong":             165,
+	"stopcd":                   166,
+	"record":                   167,
+	"rewind":                   168,
+	"phone":                    169,
+	"iso":                      170,
+	"config":                   171,
+	"homepage":                 172,
+	"refresh":                  173,
+	"exit":                     174,
+	"move":                     175,
+	"edit":                     176,
+	"scrollup":                 177,
+	"scrolldown":               178,
+	"kpleftparen":              179,
+	"kprightparen":             180,
+	"new":                      181,
+	"redo":                     182,
+	"f13":                      183,
+	"f14":                      184,
+	"f15":                      185,
+	"f16":                      186,
+	"f17":                      187,
+	"f18":                      188,
+	"f19":                      189,
+	"f20":                      190,
+	"f21":                      191,
+	"f22":                      192,
+	"f23":                      193,
+	"f24":                      194,
+	"playcd":                   200,
+	"pausecd":                  201,
+	"prog3":                    202,
+	"prog4":                    203,
+	"dashboard":                204,
+	"suspend":                  205,
+	"close":                    206,
+	"play":                     207,
+	"fastforward":              208,
+	"bassboost":                209,
+	"print":                    210,
+	"hp":                       211,
+	"camera":                   212,
+	"sound":                    213,
+	"question":                 214,
+	"email":                    215,
+	"chat":                     216,
+	"search":                   217,
+	"connect":                  218,
+	"finance":                  219,
+	"sport":                    220,
+	"shop":                     221,
+	"alterase":                 222,
+	"cancel":                   223,
+	"brightnessdown":           224,
+	"brightnessup":             225,
+	"media":                    226,
+	"switchvideomode":          227,
+	"kbdillumtoggle":           228,
+	"kbdillumdown":             229,
+	"kbdillumup":               230,
+	"send":                     231,
+	"reply":                    232,
+	"forwardmail":              233,
+	"save":                     234,
+	"documents":                235,
+	"battery":                  236,
+	"bluetooth":                237,
+	"wlan":                     238,
+	"uwb":                      239,
+	"unknown":                  240,
+	"video_next":               241,
+	"video_prev":               242,
+	"brightness_cycle":         243,
+	"brightness_auto":          244,
+	"display_off":              245,
+	"wwan":                     246,
+	"rfkill":                   247,
+	"micmute":                  248,
+	"btn_0":                    256,
+	"btn_1":                    257,
+	"btn_2":                    258,
+	"btn_3":                    259,
+	"btn_4":                    260,
+	"btn_5":                    261,
+	"btn_6":                    262,
+	"btn_7":                    263,
+	"btn_8":                    264,
+	"btn_9":                    265,
+	"btn_left":                 272,
+	"btn_right":                273,
+	"btn_middle":               274,
+	"btn_side":                 275,
+	"btn_extra":                276,
+	"btn_forward":              277,
+	"btn_back":                 278,
+	"btn_task":                 279,
+	"btn_trigger":              288,
+	"btn_thumb":                289,
+	"btn_thumb2":               290,
+	"btn_top":                  291,
+	"btn_top2":                 292,
+	"btn_pinkie":               293,
+	"btn_base":                 294,
+	"btn_base2":                295,
+	"btn_base3":                296,
+	"btn_base4":                297,
+	"btn_base5":                298,
+	"btn_base6":                299,
+	"btn_dead":                 303,
+	"btn_south":                304,
+	"btn_east":                 305,
+	"btn_c":                    306,
+	"btn_north":                307,
+	"btn_west":                 308,
+	"btn_z":                    309,
+	"btn_tl":                   310,
+	"btn_tr":                   311,
+	"btn_tl2":                  312,
+	"btn_tr2":                  313,
+	"btn_select":               314,
+	"btn_start":                315,
+	"btn_mode":                 316,
+	"btn_thumbl":               317,
+	"btn_thumbr":               318,
+	"btn_tool_pen":             320,
+	"btn_tool_rubber":          321,
+	"btn_tool_brush":           322,
+	"btn_tool_pencil":          323,
+	"btn_tool_airbrush":        324,
+	"btn_tool_finger":          325,
+	"btn_tool_mouse":           326,
+	"btn_tool_lens":            327,
+	"btn_tool_quinttap":        328,
+	"btn_stylus3":              329,
+	"btn_touch":                330,
+	"btn_stylus":               331,
+	"btn_stylus2":              332,
+	"btn_tool_doubletap":       333,
+	"btn_tool_tripletap":       334,
+	"btn_tool_quadtap":         335,
+	"btn_gear_down":            336,
+	"btn_gear_up":              337,
+	"ok":                       352,
+	"select":                   353,
+	"goto":                     354,
+	"clear":                    355,
+	"power2":                   356,
+	"option":                   357,
+	"info":                     358,
+	"time":                     359,
+	"vendor":                   360,
+	"archive":                  361,
+	"program":                  362,
+	"channel":                  363,
+	"favorites":                364,
+	"epg":                      365,
+	"pvr":                      366,
+	"mhp":                      367,
+	"language":                 368,
+	"title":                    369,
+	"subtitle":                 370,
+	"angle":                    371,
+	"full_screen":              372,
+	"mode":                     373,
+	"keyboard":                 374,
+	"aspect_ratio":             375,
+	"pc":                       376,
+	"tv":                       377,
+	"tv2":                      378,
+	"vcr":                      379,
+	"vcr2":                     380,
+	"sat":                      381,
+	"sat2":                     382,
+	"cd":                       383,
+	"tape":                     384,
+	"radio":                    385,
+	"tuner":                    386,
+	"player":                   387,
+	"text":                     388,
+	"dvd":                      389,
+	"aux":                      390,
+	"mp3":                      391,
+	"audio":                    392,
+	"video":                    393,
+	"directory":                394,
+	"list":                     395,
+	"memo":                     396,
+	"calendar":                 397,
+	"red":                      398,
+	"green":                    399,
+	"yellow":                   400,
+	"blue":                     401,
+	"channelup":                402,
+	"channeldown":              403,
+	"first":                    404,
+	"last":                     405,
+	"ab":                       406,
+	"next":                     407,
+	"restart":                  408,
+	"slow":                     409,
+	"shuffle":                  410,
+	"break":                    411,
+	"previous":                 412,
+	"digits":                   413,
+	"teen":                     414,
+	"twen":                     415,
+	"videophone":               416,
+	"games":                    417,
+	"zoomin":                   418,
+	"zoomout":                  419,
+	"zoomreset":                420,
+	"wordprocessor":            421,
+	"editor":                   422,
+	"spreadsheet":              423,
+	"graphicseditor":           424,
+	"presentation":             425,
+	"database":                 426,
+	"news":                     427,
+	"voicemail":                428,
+	"addressbook":              429,
+	"messenger":                430,
+	"displaytoggle":            431,
+	"spellcheck":               432,
+	"logoff":                   433,
+	"dollar":                   434,
+	"euro":                     435,
+	"frameback":                436,
+	"frameforward":             437,
+	"context_menu":             438,
+	"media_repeat":             439,
+	"10channelsup":             440,
+	"10channelsdown":           441,
+	"images":                   442,
+	"notification_center":      444,
+	"pickup_phone":             445,
+	"hangup_phone":             446,
+	"link_phone":               447,
+	"del_eol":                  448,
+	"del_eos":                  449,
+	"ins_line":                 450,
+	"del_line":                 451,
+	"fn":                       464,
+	"fn_esc":                   465,
+	"fn_f1":                    466,
+	"fn_f2":                    467,
+	"fn_f3":                    468,
+	"fn_f4":                    469,
+	"fn_f5":                    470,
+	"fn_f6":                    471,
+	"fn_f7":                    472,
+	"fn_f8":                    473,
+	"fn_f9":                    474,
+	"fn_f10":                   475,
+	"fn_f11":                   476,
+	"fn_f12":                   477,
+	"fn_1":                     478,
+	"fn_2":                     479,
+	"fn_d":                     480,
+	"fn_e":                     481,
+	"fn_f":                     482,
+	"fn_s":                     483,
+	"fn_b":                     484,
+	"fn_right_shift":           485,
+	"brl_dot1":                 497,
+	"brl_dot2":                 498,
+	"brl_dot3":                 499,
+	"brl_dot4":                 500,
+	"brl_dot5":                 501,
+	"brl_dot6":                 502,
+	"brl_dot7":                 503,
+	"brl_dot8":                 504,
+	"brl_dot9":                 505,
+	"brl_dot10":                506,
+	"numeric_0":                512,
+	"numeric_1":                513,
+	"numeric_2":                514,
+	"numeric_3":                515,
+	"numeric_4":                516,
+	"numeric_5":                517,
+	"numeric_6":                518,
+	"numeric_7":                519,
+	"numeric_8":                520,
+	"numeric_9":                521,
+	"numeric_star":             522,
+	"numeric_pound":            523,
+	"numeric_a":                524,
+	"numeric_b":                525,
+	"numeric_c":                526,
+	"numeric_d":                527,
+	"camera_focus":             528,
+	"wps_button":               529,
+	"touchpad_toggle":          530,
+	"touchpad_on":              531,
+	"touchpad_off":             532,
+	"camera_zoomin":            533,
+	"camera_zoomout":           534,
+	"camera_up":                535,
+	"camera_down":              536,
+	"camera_left":              537,
+	"camera_right":             538,
+	"attendant_on":             539,
+	"attendant_off":            540,
+	"attendant_toggle":         541,
+	"lights_toggle":            542,
+	"btn_dpad_up":              544,
+	"btn_dpad_down":            545,
+	"btn_dpad_left":            546,
+	"btn_dpad_right":           547,
+	"als_toggle":               560,
+	"rotate_lock_toggle":       561,
+	"refresh_rate_toggle":      562,
+	"buttonconfig":             576,
+	"taskmanager":              577,
+	"journal":                  578,
+	"controlpanel":             579,
+	"appselect":                580,
+	"screensaver":              581,
+	"voicecommand":             582,
+	"assistant":                583,
+	"kbd_layout_next":          584,
+	"emoji_picker":             585,
+	"dictate":                  586,
+	"brightness_min":           592,
+	"brightness_max":           593,
+	"kbdinputassist_prev":      608,
+	"kbdinputassist_next":      609,
+	"kbdinputassist_prevgroup": 610,
+	"kbdinputassist_nextgroup": 611,
+	"kbdinputassist_accept":    612,
+	"kbdinputassist_cancel":    613,
+	"right_up":                 614,
+	"right_down":               615,
+	"left_up":                  616,
+	"left_down":                617,
+	"root_menu":                618,
+	"media_top_menu":           619,
+	"numeric_11":               620,
+	"numeric_12":               621,
+	"audio_desc":               622,
+	"3d_mode":                  623,
+	"next_favorite":            624,
+	"stop_record":              625,
+	"pause_record":             626,
+	"vod":                      627,
+	"unmute":                   628,
+	"fastreverse":              629,
+	"slowreverse":              630,
+	"data":                     631,
+	"onscreen_keyboard":        632,
+	"privacy_screen_toggle":    633,
+	"selective_screenshot":     634,
+	"next_element":             635,
+	"previous_element":         636,
+	"autopilot_engage_toggle":  637,
+	"mark_waypoint":            638,
+	"sos":                      639,
+	"nav_chart":                640,
+	"fishing_chart":            641,
+	"single_range_radar":       642,
+	"dual_range_radar":         643,
+	"radar_overlay":            644,
+	"traditional_sonar":        645,
+	"clearvu_sonar":            646,
+	"sidevu_sonar":             647,
+	"nav_info":                 648,
+	"brightness_menu":          649,
+	"macro1":                   656,
+	"macro2":                   657,
+	"macro3":                   658,
+	"macro4":                   659,
+	"macro5":                   660,
+	"macro6":                   661,
+	"macro7":                   662,
+	"macro8":                   663,
+	"macro9":                   664,
+	"macro10":                  665,
+	"macro11":                  666,
+	"macro12":                  667,
+	"macro13":                  668,
+	"macro14":                  669,
+	"macro15":                  670,
+	"macro16":                  671,
+	"macro17":                  672,
+	"macro18":                  673,
+	"macro19":                  674,
+	"macro20":                  675,
+	"macro21":                  676,
+	"macro22":                  677,
+	"macro23":                  678,
+	"macro24":                  679,
+	"macro25":                  680,
+	"macro26":                  681,
+	"macro27":                  682,
+	"macro28":                  683,
+	"macro29":                  684,
+	"macro30":                  685,
+	"macro_record_start":       688,
+	"macro_record_stop":        689,
+	"macro_preset_cycle":       690,
+	"macro_preset1":            691,
+	"macro_preset2":            692,
+	"macro_preset3":            693,
+	"kbd_lcd_menu1":            696,
+	"kbd_lcd_menu2":            697,
+	"kbd_lcd_menu3":            698,
+	"kbd_lcd_menu4":            699,
+	"kbd_lcd_menu5":            700,
+	"btn_trigger_happy1":       704,
+	"btn_trigger_happy2":       705,
+	"btn_trigger_happy3":       706,
+	"btn_trigger_happy4":       707,
+	"btn_trigger_happy5":       708,
+	"btn_trigger_happy6":       709,
+	"btn_trigger_happy7":       710,
+	"btn_trigger_happy8":       711,
+	"btn_trigger_happy9":       712,
+	"btn_trigger_happy10":      713,
+	"btn_trigger_happy11":      714,
+	"btn_trigger_happy12":      715,
+	"btn_trigger_happy13":      716,
+	"btn_trigger_happy14":      717,
+	"btn_trigger_happy15":      718,
+	"btn_trigger_happy16":      719,
+	"btn_trigger_happy17":      720,
+	"btn_trigger_happy18":      721,
+	"btn_trigger_happy19":      722,
+	"btn_trigger_happy20":      723,
+	"btn_trigger_happy21":      724,
+	"btn_trigger_happy22":      725,
+	"btn_trigger_happy23":      726,
+	"btn_trigger_happy24":      727,
+	"btn_trigger_happy25":      728,
+	"btn_trigger_happy26":      729,
+	"btn_trigger_happy27":      730,
+	"btn_trigger_happy28":      731,
+	"btn_trigger_happy29":      732,
+	"btn_trigger_happy30":      733,
+	"btn_trigger_happy31":      734,
+	"btn_trigger_happy32":      735,
+	"btn_trigger_happy33":      736,
+	"btn_trigger_happy34":      737,
+	"btn_trigger_happy35":      738,
+	"btn_trigger_happy36":      739,
+	"btn_trigger_happy37":      740,
+	"btn_trigger_happy38":      741,
+	"btn_trigger_happy39":      742,
+	"btn_trigger_happy40":      743,
+}
+
+// alternativeKeyAliases contains further names for keys that already have an alias in keyAliases, they are accepted
+// in the config but never used for printing a key.
+var alternativeKeyAliases = map[string]uint16{
+	"hanguel":           122,
+	"coffee":            152,
+	"direction":         153,
+	"all_applications":  204,
+	"brightness_zero":   244,
+	"wimax":             246,
+	"btn_misc":          256,
+	"btn_mouse":         272,
+	"btn_joystick":      288,
+	"btn_a":             304,
+	"btn_gamepad":       304,
+	"btn_b":             305,
+	"btn_x":             307,
+	"btn_y":             308,
+	"btn_digi":          320,
+	"btn_wheel":         336,
+	"zoom":              372,
+	"screen":            375,
+	"brightness_toggle": 431,
+	"btn_trigger_happy": 704,
 }
 var keyAliasesReversed = make(map[uint16]string)
 
@@ -249,11 +664,45 @@ var modifiersKeyCodes []uint16
 type MouseButton string
 
 const (
-	ButtonLeft   MouseButton = "left"
-	ButtonMiddle MouseButton = "middle"
-	ButtonRight  MouseButton = "right"
+	ButtonLeft    MouseButton = "left"
+	ButtonMiddle  MouseButton = "middle"
+	ButtonRight   MouseButton = "right"
+	ButtonSide    MouseButton = "side"
+	ButtonExtra   MouseButton = "extra"
+	ButtonForward MouseButton = "forward"
+	ButtonBack    MouseButton = "back"
+	ButtonTask    MouseButton = "task"
 )
 
+// mouseButtonCodes maps the mouse buttons to their codes, i.e. BTN_LEFT to BTN_TASK of input-event-codes.h.
+var mouseButtonCodes = map[MouseButton]uint16{
+	ButtonLeft:    0x110,
+	ButtonRight:   0x111,
+	ButtonMiddle:  0x112,
+	ButtonSide:    0x113,
+	ButtonExtra:   0x114,
+	ButtonForward: 0x115,
+	ButtonBack:    0x116,
+	ButtonTask:    0x117,
+}
+
+// Code returns the event code of the button, e.g. BTN_LEFT for ButtonLeft.
+func (b MouseButton) Code() uint16 {
+	return mouseButtonCodes[b]
+}
+
+// MouseButtons returns all supported mouse buttons, ordered by their codes.
+func MouseButtons() []MouseButton {
+	buttons := make([]MouseButton, 0, len(mouseButtonCodes))
+	for button := range mouseButtonCodes {
+		buttons = append(buttons, button)
+	}
+	slices.SortFunc(buttons, func(a, b MouseButton) int {
+		return int(a.Code()) - int(b.Code())
+	})
+	return buttons
+}
+
 func init() {
 	// init keyAliasesReversed
 	for alias, code := range keyAliases {
@@ -268,8 +717,42 @@ func init() {
 	}
 }
 
+// GetKeyCode returns the code of the key with the given alias. Besides the aliases in keyAliases, the names from
+// input-event-codes.h are accepted as well, i.e. with a KEY_ prefix and in upper case, e.g. KEY_LEFTSHIFT.
 func GetKeyCode(alias string) (code uint16, exists bool) {
-	code, exists = keyAliases[alias]
+	if code, exists = lookupKeyAlias(alias); exists {
+		return code, exists
+	}
+	name := strings.ToLower(alias)
+	if withoutPrefix, ok := strings.CutPrefix(name, "key_"); ok {
+		name = withoutPrefix
+		// the digits are named k0 to k9, since plain numbers are key codes
+		if len(name) == 1 && name[0] >= '0' && name[0] <= '9' {
+			name = "k" + name
+		}
+	}
+	return lookupKeyAlias(name)
+}
+
+// AllKeyAliases returns all aliases of keys including the alternative ones, without the wildcard key.
+func AllKeyAliases() map[string]uint16 {
+	aliases := make(map[string]uint16, len(keyAliases)+len(alternativeKeyAliases))
+	for alias, code := range keyAliases {
+		if code != WildcardKey {
+			aliases[alias] = code
+		}
+	}
+	for alias, code := range alternativeKeyAliases {
+		aliases[alias] = code
+	}
+	return aliases
+}
+
+func lookupKeyAlias(alias string) (code uint16, exists bool) {
+	if code, exists = keyAliases[alias]; exists {
+		return code, exists
+	}
+	code, exists = alternativeKeyAliases[alias]
 	return code, exists
 }
 
@@ -0,0 +1,219 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	// UnicodeInputCtrlShiftU enters characters by their hex code after pressing ctrl+shift+u, as supported by GTK and
+	// IBus
+	UnicodeInputCtrlShiftU = "ctrl-shift-u"
+	// UnicodeInputNone does not support characters that are not part of the layout
+	UnicodeInputNone = "none"
+)
+
+// layoutKey is a key of a keyboard layout with the characters it produces on its levels, which are in order: no
+// modifier, shift, AltGr and shift+AltGr. A space means that the level produces no character (or a dead key).
+type layoutKey struct {
+	key    string
+	levels string
+}
+
+// the modifiers that select the levels of a layoutKey
+var layoutLevelModifiers = [][]string{
+	{},
+	{"leftshift"},
+	{"rightalt"},
+	{"leftshift", "rightalt"},
+}
+
+// keysUS is the US QWERTY layout.
+var keysUS = append(letterKeys(nil), []layoutKey{
+	{"grave", "`~"},
+	{"k1", "1!"},
+	{"k2", "2@"},
+	{"k3", "3#"},
+	{"k4", "4$"},
+	{"k5", "5%"},
+	{"k6", "6^"},
+	{"k7", "7&"},
+	{"k8", "8*"},
+	{"k9", "9("},
+	{"k0", "0)"},
+	{"minus", "-_"},
+	{"equal", "=+"},
+	{"leftbrace", "[{"},
+	{"rightbrace", "]}"},
+	{"backslash", "\\|"},
+	{"semicolon", ";:"},
+	{"apostrophe", "'\""},
+	{"comma", ",<"},
+	{"dot", ".>"},
+	{"slash", "/?"},
+}...)
+
+// keysDE is the German QWERTZ layout, the dead keys are omitted.
+var keysDE = append(letterKeys(map[string]string{"y": "zZ", "z": "yY", "q": "qQ@", "e": "eE€", "m": "mMµ"}),
+	[]layoutKey{
+		{"grave", " °"},
+		{"k1", "1!"},
+		{"k2", "2\"²"},
+		{"k3", "3§³"},
+		{"k4", "4$"},
+		{"k5", "5%"},
+		{"k6", "6&"},
+		{"k7", "7/{"},
+		{"k8", "8(["},
+		{"k9", "9)]"},
+		{"k0", "0=}"},
+		{"minus", "ß?\\"},
+		{"leftbrace", "üÜ"},
+		{"rightbrace", "+*~"},
+		{"backslash", "#'"},
+		{"semicolon", "öÖ"},
+		{"apostrophe", "äÄ"},
+		{"comma", ",;"},
+		{"dot", ".:"},
+		{"slash", "-_"},
+		{"102nd", "<>|"},
+	}...)
+
+// keyboardLayouts contains the layouts that can be chosen with the typeLayout option.
+var keyboardLayouts = map[string][]layoutKey{
+	"us": keysUS,
+	"de": keysDE,
+}
+
+// letterKeys returns the keys a to z with their lower and upper case letters, where the given keys are replaced.
+func letterKeys(replaced map[string]string) []layoutKey {
+	var keys []layoutKey
+	for c := 'a'; c <= 'z'; c++ {
+		name := string(c)
+		levels, ok := replaced[name]
+		if !ok {
+			levels = name + strings.ToUpper(name)
+		}
+		keys = append(keys, layoutKey{key: name, levels: levels})
+	}
+	return keys
+}
+
+// typeLayout maps characters to the key combos that produce them, it is used by the type action.
+type typeLayout struct {
+	name         string
+	chars        map[rune][]uint16
+	unicodeInput string
+}
+
+// parseTypeLayout creates the layout with the given name, where the given keys are added or replaced.
+func (p *bindingParser) parseTypeLayout(
+	name string,
+	extraKeys map[string]string,
+	unicodeInput string,
+) (*typeLayout, error) {
+	if name == "" {
+		name = "us"
+	}
+	keys, ok := keyboardLayouts[name]
+	if !ok {
+		var names []string
+		for n := range keyboardLayouts {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("unknown typeLayout '%s', available are: %s", name, strings.Join(names, ", "))
+	}
+	switch unicodeInput {
+	case "":
+		unicodeInput = UnicodeInputCtrlShiftU
+	case UnicodeInputCtrlShiftU, UnicodeInputNone:
+	default:
+		return nil, fmt.Errorf("unknown typeUnicodeInput '%s', must be %s or %s", unicodeInput,
+			UnicodeInputCtrlShiftU, UnicodeInputNone)
+	}
+
+	l := typeLayout{
+		name:         name,
+		chars:        make(map[rune][]uint16),
+		unicodeInput: unicodeInput,
+	}
+	l.chars[' '] = []uint16{mustGetKeyCode("space")}
+	l.chars['\n'] = []uint16{mustGetKeyCode("enter")}
+	l.chars['\t'] = []uint16{mustGetKeyCode("tab")}
+	for _, key := range keys {
+		code := mustGetKeyCode(key.key)
+		for level, char := range []rune(key.levels) {
+			if char == ' ' {
+				continue
+			}
+			var combo []uint16
+			for _, modifier := range layoutLevelModifiers[level] {
+				combo = append(combo, mustGetKeyCode(modifier))
+			}
+			l.chars[char] = append(combo, code)
+		}
+	}
+	for char, keys := range extraKeys {
+		if utf8.RuneCountInString(char) != 1 {
+			return nil, fmt.Errorf("typeLayoutKeys: '%s' is not a single character", char)
+		}
+		combo, err := p.parseKeyCombo(keys)
+		if err != nil {
+			return nil, fmt.Errorf("typeLayoutKeys: failed to parse the keys '%s' of '%s': %v", keys, char, err)
+		}
+		r, _ := utf8.DecodeRuneInString(char)
+		l.chars[r] = combo
+	}
+	return &l, nil
+}
+
+// steps returns the macro steps that type the given text.
+func (l *typeLayout) steps(text string) ([]MacroStep, error) {
+	var steps []MacroStep
+	for _, char := range text {
+		if combo, ok := l.chars[char]; ok {
+			steps = append(steps, MacroStep{Type: MacroStepTap, Keys: combo})
+			continue
+		}
+		if l.unicodeInput == UnicodeInputNone {
+			return nil, fmt.Errorf("the character '%c' is not part of the layout %s", char, l.name)
+		}
+		unicodeSteps, err := l.unicodeSteps(char)
+		if err != nil {
+			return nil, err
+		}
+		steps = append(steps, unicodeSteps...)
+	}
+	return steps, nil
+}
+
+// unicodeSteps returns the macro steps that enter the given character by its hex code.
+func (l *typeLayout) unicodeSteps(char rune) ([]MacroStep, error) {
+	steps := []MacroStep{{
+		Type: MacroStepTap,
+		Keys: []uint16{mustGetKeyCode("leftctrl"), mustGetKeyCode("leftshift"), mustGetKeyCode("u")},
+	}}
+	for _, digit := range strconv.FormatInt(int64(char), 16) {
+		combo, ok := l.chars[digit]
+		if !ok {
+			return nil, fmt.Errorf("the character '%c' cannot be entered, since the layout %s lacks '%c'", char,
+				l.name, digit)
+		}
+		steps = append(steps, MacroStep{Type: MacroStepTap, Keys: combo})
+	}
+	steps = append(steps, MacroStep{Type: MacroStepTap, Keys: l.chars[' ']})
+	return steps, nil
+}
+
+// mustGetKeyCode returns the code of a built-in key name, it panics if the name does not exist.
+func mustGetKeyCode(name string) uint16 {
+	code, ok := GetKeyCode(name)
+	if !ok {
+		panic(fmt.Sprintf("unknown key %s", name))
+	}
+	return code
+}
@@ -0,0 +1,80 @@
+package config
+
+import (
+	"testing"
+)
+
+// typeSteps returns the macro steps that tap the given key combos.
+func typeSteps(combos ...[]uint16) []MacroStep {
+	var steps []MacroStep
+	for _, combo := range combos {
+		steps = append(steps, MacroStep{Type: MacroStepTap, Keys: combo})
+	}
+	return steps
+}
+
+func TestTypeLayout(t *testing.T) {
+	conf := parseTestConfig(t, `
+layers:
+- name: initial
+  bindings:
+    a: type Hy@
+    b: type "a\nb"
+    c: type é
+`)
+	bindings := conf.Layers[0].Bindings
+	assertBinding(t, bindings[30], TypeBinding{Text: "Hy@", Steps: typeSteps(
+		[]uint16{42, 35}, []uint16{21}, []uint16{42, 3})})
+	assertBinding(t, bindings[48], TypeBinding{Text: "a\nb", Steps: typeSteps(
+		[]uint16{30}, []uint16{28}, []uint16{48})})
+	// entered by its code point e9 with ctrl+shift+u
+	assertBinding(t, bindings[46], TypeBinding{Text: "é", Steps: typeSteps(
+		[]uint16{29, 42, 22}, []uint16{18}, []uint16{10}, []uint16{57})})
+
+	conf = parseTestConfig(t, `
+typeLayout: de
+typeLayoutKeys:
+  "é": rightalt+e
+layers:
+- name: initial
+  bindings:
+    a: type zü@
+    b: type é
+`)
+	bindings = conf.Layers[0].Bindings
+	assertBinding(t, bindings[30], TypeBinding{Text: "zü@", Steps: typeSteps(
+		[]uint16{21}, []uint16{26}, []uint16{100, 16})})
+	assertBinding(t, bindings[48], TypeBinding{Text: "é", Steps: typeSteps([]uint16{100, 18})})
+}
+
+func TestTypeLayoutErrors(t *testing.T) {
+	assertParseError(t, `
+typeLayout: fr
+layers:
+- name: initial
+`, "unknown typeLayout 'fr', available are: de, us")
+	assertParseError(t, `
+typeUnicodeInput: alt-numpad
+layers:
+- name: initial
+`, "unknown typeUnicodeInput 'alt-numpad'")
+	assertParseError(t, `
+typeLayoutKeys:
+  "ab": a
+layers:
+- name: initial
+`, "typeLayoutKeys: 'ab' is not a single character")
+	assertParseError(t, `
+typeUnicodeInput: none
+layers:
+- name: initial
+  bindings:
+    a: type é
+`, "the character 'é' is not part of the layout us")
+	assertParseError(t, `
+layers:
+- name: initial
+  bindings:
+    a: type ""
+`, "the text must not be empty")
+}
@@ -0,0 +1,51 @@
+package config
+
+import (
+	"fmt"
+)
+
+// Position is a location in a config file.
+type Position struct {
+	File   string
+	Line   int
+	Column int
+}
+
+// String returns the position in the form file:line:column, where the parts that are unknown are omitted.
+func (p Position) String() string {
+	if p.Line == 0 {
+		return p.File
+	}
+	if p.File == "" && p.Column == 0 {
+		return fmt.Sprintf("line %d", p.Line)
+	}
+	if p.File == "" {
+		return fmt.Sprintf("line %d, column %d", p.Line, p.Column)
+	}
+	if p.Column == 0 {
+		return fmt.Sprintf("%s:%d", p.File, p.Line)
+	}
+	return fmt.Sprintf("%s:%d:%d", p.File, p.Line, p.Column)
+}
+
+// PositionError is an error that occurred at a specific position in a config file.
+type PositionError struct {
+	Pos Position
+	Err error
+}
+
+func (e *PositionError) Error() string {
+	if pos := e.Pos.String(); pos != "" {
+		return fmt.Sprintf("%s: %v", pos, e.Err)
+	}
+	return e.Err.Error()
+}
+
+func (e *PositionError) Unwrap() error {
+	return e.Err
+}
+
+// errorAt returns a PositionError with the given position and message.
+func errorAt(pos Position, format string, args ...any) error {
+	return &PositionError{Pos: pos, Err: fmt.Errorf(format, args...)}
+}
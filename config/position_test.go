@@ -0,0 +1,95 @@
+package config
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestErrorPositions(t *testing.T) {
+	dir := writeConfigFiles(t, map[string]string{
+		"binding.yaml": `
+layers:
+- name: initial
+  bindings:
+    a: foo bar
+`,
+		"key.yaml": `
+layers:
+- name: initial
+  bindings:
+    b: c
+    nokey: a
+`,
+		"included.yaml": `
+layers:
+- name: initial
+  bindings:
+    a: {action: tap-hold, tap: a}
+`,
+		"include.yaml": `
+include: [included.yaml]
+layers:
+- name: initial
+  bindings:
+    b: c
+`,
+	})
+	tests := []struct {
+		file     string
+		expected string
+	}{
+		{"binding.yaml", "binding.yaml:5:8: failed to parse the binding 'foo bar'"},
+		{"key.yaml", "key.yaml:6:12: failed to parse the key 'nokey'"},
+		{"include.yaml", "included.yaml:5:8: failed to parse the binding"},
+	}
+	for _, test := range tests {
+		_, err := ReadConfig(filepath.Join(dir, test.file))
+		if err == nil {
+			t.Errorf("%s: expected an error", test.file)
+			continue
+		}
+		expected := filepath.Join(dir, test.expected)
+		if !strings.HasPrefix(err.Error(), expected) {
+			t.Errorf("%s: expected the error to start with '%s' but got '%s'", test.file, expected, err)
+		}
+	}
+}
+
+func TestCheckKeys(t *testing.T) {
+	var document yaml.Node
+	err := yaml.Unmarshal([]byte(`
+comboTime: 20
+comboTim: 30
+comboTime: 40
+layers:
+- name: initial
+  bindings:
+    a: {action: tap-hold, tap: a, hold: b}
+  bindings: {}
+  pasThrough: false
+`), &document)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var warnings []string
+	for _, warning := range checkKeys(&document, "config.yaml") {
+		warnings = append(warnings, warning.Error())
+	}
+	expected := []string{
+		"config.yaml:4:1: the key 'comboTime' is already defined at line 2, the last one is used",
+		"config.yaml:9:3: the key 'bindings' is already defined at line 7, the last one is used",
+		"config.yaml:3:1: unknown key 'comboTim'",
+		"config.yaml:10:3: unknown key 'pasThrough'",
+	}
+	if len(warnings) != len(expected) {
+		t.Fatalf("expected %q but got %q", expected, warnings)
+	}
+	for i := range expected {
+		if warnings[i] != expected[i] {
+			t.Errorf("expected '%s' but got '%s'", expected[i], warnings[i])
+		}
+	}
+}
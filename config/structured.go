@@ -0,0 +1,455 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// RawBinding is a binding in the config file, which is either given as a string or in a structured form as a
+// mapping, e.g. {action: tap-hold, tap: a, hold: {action: toggle-layer, layer: mouse}, timeout: 200}.
+// The fields of a mapping are RawBindings again, which can also be lists, e.g. for the bindings of multi.
+// Scalars are always kept as strings, so that e.g. the key y is not interpreted as a boolean.
+type RawBinding struct {
+	// the string form of the binding, only used if Fields and List are nil
+	Text string
+	// the structured form of the binding
+	Fields map[string]RawBinding
+	// only used for fields of the structured form
+	List []RawBinding
+	// whether the binding is a YAML null like ~, which is the TransparentMarker, but not an empty value
+	isNull bool
+
+	pos Position
+}
+
+func (b *RawBinding) UnmarshalYAML(node *yaml.Node) error {
+	b.pos = Position{Line: node.Line, Column: node.Column}
+	switch node.Kind {
+	case yaml.ScalarNode:
+		if node.Tag == "!!null" {
+			b.isNull = node.Value != ""
+		} else {
+			b.Text = node.Value
+		}
+		return nil
+	case yaml.SequenceNode:
+		// decoded item by item, since yaml.v3 would drop null items like ~
+		b.List = make([]RawBinding, len(node.Content))
+		for i, item := range node.Content {
+			if err := b.List[i].UnmarshalYAML(item); err != nil {
+				return err
+			}
+		}
+		return nil
+	case yaml.MappingNode:
+		var fields RawBindings
+		if err := fields.UnmarshalYAML(node); err != nil {
+			return err
+		}
+		b.Fields = fields
+		return nil
+	case yaml.AliasNode:
+		return b.UnmarshalYAML(node.Alias)
+	}
+	return errorAt(b.pos, "a binding must be either a string or a mapping")
+}
+
+// RawBindings maps names to bindings, e.g. the keys of a layer to their bindings. It is decoded entry by entry, since
+// yaml.v3 does not call UnmarshalYAML for null values like ~, which could then not be told apart from empty values.
+type RawBindings map[string]RawBinding
+
+func (m *RawBindings) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+	if node.Kind != yaml.MappingNode {
+		return errorAt(Position{Line: node.Line, Column: node.Column}, "expected a mapping of bindings")
+	}
+	*m = make(RawBindings, len(node.Content)/2)
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		var binding RawBinding
+		if err := binding.UnmarshalYAML(node.Content[i+1]); err != nil {
+			return err
+		}
+		(*m)[node.Content[i].Value] = binding
+	}
+	return nil
+}
+
+// withFile returns a copy of the binding where the file of all positions is set.
+func (b RawBinding) withFile(fileName string) RawBinding {
+	b.pos.File = fileName
+	if b.Fields != nil {
+		fields := make(map[string]RawBinding, len(b.Fields))
+		for name, field := range b.Fields {
+			fields[name] = field.withFile(fileName)
+		}
+		b.Fields = fields
+	}
+	if b.List != nil {
+		list := make([]RawBinding, len(b.List))
+		for i, item := range b.List {
+			list[i] = item.withFile(fileName)
+		}
+		b.List = list
+	}
+	return b
+}
+
+// isTransparent checks if the binding is the TransparentMarker, an empty binding is not transparent.
+func (b RawBinding) isTransparent() bool {
+	if b.Fields != nil || b.List != nil {
+		return false
+	}
+	return b.isNull || strings.TrimSpace(b.Text) == TransparentMarker
+}
+
+// String returns the binding as it was given in the config file, used for error messages.
+func (b RawBinding) String() string {
+	if b.Fields != nil {
+		return fmt.Sprintf("%v", b.Fields)
+	}
+	if b.List != nil {
+		return fmt.Sprintf("%v", b.List)
+	}
+	return b.Text
+}
+
+// parseRawBinding parses a binding in either the string or the structured form.
+func (p *bindingParser) parseRawBinding(rawBinding RawBinding) (Binding, error) {
+	if rawBinding.List != nil {
+		return nil, fmt.Errorf("a binding must be either a string or a mapping")
+	}
+	if rawBinding.Fields == nil {
+		return p.parseBinding(rawBinding.Text)
+	}
+	return p.parseStructuredBinding(rawBinding.Fields)
+}
+
+// parseStructuredBinding parses a binding in the structured form, which is a mapping with an action field and
+// further fields depending on the action.
+func (p *bindingParser) parseStructuredBinding(fieldMap map[string]RawBinding) (binding Binding, err error) {
+	f := structuredFields{fields: fieldMap, used: make(map[string]bool)}
+	action, err := f.string("action")
+	if err != nil {
+		return nil, err
+	}
+
+	switch Action(action) {
+	case ActionMulti:
+		values, err := f.list("bindings")
+		if err != nil {
+			return nil, err
+		}
+		if len(values) < 2 {
+			return nil, fmt.Errorf("action requires at least two bindings")
+		}
+		multiBinding := MultiBinding{}
+		for _, value := range values {
+			b, err := p.parseRawBinding(value)
+			if err != nil {
+				return nil, err
+			}
+			multiBinding.Bindings = append(multiBinding.Bindings, b)
+		}
+		binding = multiBinding
+	case ActionTapHold, ActionTapHoldNext, ActionTapHoldNextRelease:
+		tapHoldBinding := TapHoldBinding{
+			TapOnNext:        Action(action) == ActionTapHoldNext,
+			TapOnNextRelease: Action(action) == ActionTapHoldNextRelease,
+		}
+		if tapHoldBinding.TapBinding, err = f.binding(p, "tap"); err != nil {
+			return nil, err
+		}
+		if tapHoldBinding.HoldBinding, err = f.binding(p, "hold"); err != nil {
+			return nil, err
+		}
+		timeout, err := f.number("timeout")
+		if err != nil {
+			return nil, err
+		}
+		tapHoldBinding.TimeoutMs = int64(timeout)
+		binding = tapHoldBinding
+	case ActionTapDance:
+		tapDanceBinding := TapDanceBinding{}
+		if tapDanceBinding.TapBindings, err = f.bindingList(p, "taps"); err != nil {
+			return nil, err
+		}
+		if _, ok := f.fields["holds"]; ok {
+			if tapDanceBinding.HoldBindings, err = f.bindingList(p, "holds"); err != nil {
+				return nil, err
+			}
+		}
+		if tapDanceBinding.MaxTaps() < 2 && len(tapDanceBinding.HoldBindings) == 0 {
+			return nil, fmt.Errorf("action requires at least two taps or a hold binding")
+		}
+		timeout, err := f.number("timeout")
+		if err != nil {
+			return nil, err
+		}
+		if timeout <= 0 {
+			return nil, fmt.Errorf("field 'timeout' must be positive")
+		}
+		tapDanceBinding.TimeoutMs = int64(timeout)
+		binding = tapDanceBinding
+	case ActionModLayer:
+		keyName, err := f.string("key")
+		if err != nil {
+			return nil, err
+		}
+		key, err := p.parseKey(keyName)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse the key '%v': %v", keyName, err)
+		}
+		layer, err := f.string("layer")
+		if err != nil {
+			return nil, err
+		}
+		binding = ModLayerBinding{ModKey: key, Layer: layer}
+	case ActionLayer, ActionToggleLayer, ActionPushLayer, ActionOneShotLayer:
+		layer, err := f.string("layer")
+		if err != nil {
+			return nil, err
+		}
+		switch Action(action) {
+		case ActionLayer:
+			binding = LayerBinding{Layer: layer}
+		case ActionToggleLayer:
+			binding = ToggleLayerBinding{Layer: layer}
+		case ActionOneShotLayer:
+			binding = OneShotLayerBinding{Layer: layer}
+		default:
+			binding = PushLayerBinding{Layer: layer}
+		}
+	case ActionPopLayer:
+		binding = PopLayerBinding{}
+	case ActionLayerBack:
+		binding = LayerBackBinding{}
+	case ActionReloadConfig:
+		binding = ReloadConfigBinding{}
+	case ActionNop:
+		binding = NopBinding{}
+	case ActionStopMacro:
+		binding = StopMacroBinding{}
+	case ActionLeader:
+		binding = LeaderBinding{}
+	case ActionRepeat:
+		binding = RepeatBinding{Count: 1}
+	case ActionRepeatCount:
+		rawCount, err := f.string("count")
+		if err != nil {
+			return nil, err
+		}
+		count, err := parseRepeatCount(rawCount)
+		if err != nil {
+			return nil, err
+		}
+		binding = RepeatBinding{Count: count}
+	case ActionMove:
+		x, err := f.number("x")
+		if err != nil {
+			return nil, err
+		}
+		y, err := f.number("y")
+		if err != nil {
+			return nil, err
+		}
+		binding = MoveBinding{X: x, Y: y}
+	case ActionScroll:
+		direction, err := f.string("direction")
+		if err != nil {
+			return nil, err
+		}
+		if binding, err = parseScrollDirection(direction); err != nil {
+			return nil, err
+		}
+	case ActionSpeed:
+		speed, err := f.number("speed")
+		if err != nil {
+			return nil, err
+		}
+		binding = SpeedBinding{Speed: speed}
+	case ActionButton:
+		name, err := f.string("button")
+		if err != nil {
+			return nil, err
+		}
+		button, err := parseMouseButton(name)
+		if err != nil {
+			return nil, err
+		}
+		binding = ButtonBinding{Button: button}
+	case ActionExec:
+		command, err := f.string("command")
+		if err != nil {
+			return nil, err
+		}
+		binding = ExecBinding{Command: command}
+	case ActionExecPressRelease:
+		pressCommand, err := f.string("press")
+		if err != nil {
+			return nil, err
+		}
+		releaseCommand, err := f.string("release")
+		if err != nil {
+			return nil, err
+		}
+		binding = ExecPressReleaseBinding{PressCommand: pressCommand, ReleaseCommand: releaseCommand}
+	case ActionMacro:
+		values, err := f.list("steps")
+		if err != nil {
+			return nil, err
+		}
+		macroBinding := MacroBinding{}
+		for _, value := range values {
+			if value.Fields != nil || value.List != nil {
+				return nil, fmt.Errorf("the steps of a macro must be strings")
+			}
+			steps, err := p.parseMacroSteps(value.Text)
+			if err != nil {
+				return nil, err
+			}
+			macroBinding.Steps = append(macroBinding.Steps, steps...)
+		}
+		if len(macroBinding.Steps) == 0 {
+			return nil, fmt.Errorf("action requires at least one step")
+		}
+		binding = macroBinding
+	case ActionOneShot:
+		keys, err := f.string("keys")
+		if err != nil {
+			return nil, err
+		}
+		combo, err := p.parseKeyCombo(keys)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse the keys '%v': %v", keys, err)
+		}
+		binding = OneShotBinding{Keys: combo}
+	case ActionType:
+		text, err := f.string("text")
+		if err != nil {
+			return nil, err
+		}
+		if binding, err = p.parseTypeBinding(text); err != nil {
+			return nil, err
+		}
+	case ActionKey:
+		keys, err := f.string("keys")
+		if err != nil {
+			return nil, err
+		}
+		combo, err := p.parseKeyCombo(keys)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse the keys '%v': %v", keys, err)
+		}
+		binding = KeyBinding{KeyCombo: combo}
+	default:
+		return nil, fmt.Errorf("unknown action '%v'", action)
+	}
+
+	if err := f.checkUnused(action); err != nil {
+		return nil, err
+	}
+	return binding, nil
+}
+
+// structuredFields gives access to the fields of a structured binding and keeps track of which have been used.
+type structuredFields struct {
+	fields map[string]RawBinding
+	used   map[string]bool
+}
+
+func (f *structuredFields) get(name string) (RawBinding, error) {
+	value, ok := f.fields[name]
+	if !ok {
+		return RawBinding{}, fmt.Errorf("missing field '%s'", name)
+	}
+	f.used[name] = true
+	return value, nil
+}
+
+func (f *structuredFields) string(name string) (string, error) {
+	value, err := f.get(name)
+	if err != nil {
+		return "", err
+	}
+	if value.Fields != nil || value.List != nil {
+		return "", fmt.Errorf("field '%s' must be a string", name)
+	}
+	return value.Text, nil
+}
+
+func (f *structuredFields) number(name string) (float64, error) {
+	value, err := f.string(name)
+	if err != nil {
+		return 0, err
+	}
+	number, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	if err != nil {
+		return 0, fmt.Errorf("field '%s' must be a number", name)
+	}
+	return number, nil
+}
+
+func (f *structuredFields) list(name string) ([]RawBinding, error) {
+	value, err := f.get(name)
+	if err != nil {
+		return nil, err
+	}
+	if value.List == nil {
+		return nil, fmt.Errorf("field '%s' must be a list", name)
+	}
+	return value.List, nil
+}
+
+func (f *structuredFields) binding(p *bindingParser, name string) (Binding, error) {
+	value, err := f.get(name)
+	if err != nil {
+		return nil, err
+	}
+	binding, err := p.parseRawBinding(value)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse field '%s': %w", name, err)
+	}
+	return binding, nil
+}
+
+// bindingList returns the bindings of a list field, where transparent entries are nil.
+func (f *structuredFields) bindingList(p *bindingParser, name string) ([]Binding, error) {
+	values, err := f.list(name)
+	if err != nil {
+		return nil, err
+	}
+	var bindings []Binding
+	for i, value := range values {
+		if value.isTransparent() {
+			bindings = append(bindings, nil)
+			continue
+		}
+		binding, err := p.parseRawBinding(value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse entry %d of field '%s': %w", i+1, name, err)
+		}
+		bindings = append(bindings, binding)
+	}
+	return bindings, nil
+}
+
+// checkUnused returns an error if there are fields that do not belong to the action.
+func (f *structuredFields) checkUnused(action string) error {
+	var unused []string
+	for name := range f.fields {
+		if !f.used[name] {
+			unused = append(unused, name)
+		}
+	}
+	if len(unused) > 0 {
+		sort.Strings(unused)
+		return fmt.Errorf("unknown fields for action '%s': %s", action, strings.Join(unused, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package config
+
+import (
+	"testing"
+)
+
+func TestStructuredBindings(t *testing.T) {
+	conf := parseTestConfig(t, `
+layers:
+- name: initial
+  bindings:
+    a: {action: tap-hold, tap: a, hold: {action: toggle-layer, layer: mouse}, timeout: 200}
+    b:
+      action: multi
+      bindings:
+      - {action: exec, command: "echo one; echo two"}
+      - y
+    c: {action: tap-dance, taps: [esc, layer mouse], holds: [~, toggle-layer arrows], timeout: 150}
+    d: {action: macro, steps: [h i, wait 50, press leftshift]}
+    e: {action: key, keys: leftctrl+c}
+    f: {action: repeat-count, count: 3}
+`)
+	bindings := conf.Layers[0].Bindings
+	assertBinding(t, bindings[30], TapHoldBinding{
+		TapBinding:  KeyBinding{KeyCombo: []uint16{30}},
+		HoldBinding: ToggleLayerBinding{Layer: "mouse"},
+		TimeoutMs:   200,
+	})
+	assertBinding(t, bindings[48], MultiBinding{Bindings: []Binding{
+		ExecBinding{Command: "echo one; echo two"},
+		KeyBinding{KeyCombo: []uint16{21}},
+	}})
+	assertBinding(t, bindings[46], TapDanceBinding{
+		TapBindings:  []Binding{KeyBinding{KeyCombo: []uint16{1}}, LayerBinding{Layer: "mouse"}},
+		HoldBindings: []Binding{nil, ToggleLayerBinding{Layer: "arrows"}},
+		TimeoutMs:    150,
+	})
+	assertBinding(t, bindings[32], MacroBinding{Steps: []MacroStep{
+		{Type: MacroStepTap, Keys: []uint16{35}},
+		{Type: MacroStepTap, Keys: []uint16{23}},
+		{Type: MacroStepWait, WaitMs: 50},
+		{Type: MacroStepPress, Keys: []uint16{42}},
+	}})
+	assertBinding(t, bindings[18], KeyBinding{KeyCombo: []uint16{29, 46}})
+	assertBinding(t, bindings[33], RepeatBinding{Count: 3})
+}
+
+func TestStructuredBindingErrors(t *testing.T) {
+	tests := []struct {
+		binding  string
+		expected string
+	}{
+		{"{action: foo}", "unknown action 'foo'"},
+		{"{layer: mouse}", "missing field 'action'"},
+		{"{action: toggle-layer}", "missing field 'layer'"},
+		{"{action: toggle-layer, layer: mouse, timeout: 200}", "unknown fields for action 'toggle-layer': timeout"},
+		{"{action: tap-hold, tap: a, hold: b, timeout: soon}", "field 'timeout' must be a number"},
+		{"{action: multi, bindings: a}", "field 'bindings' must be a list"},
+		{"{action: multi, bindings: [a]}", "action requires at least two bindings"},
+		{"{action: multi, bindings: [a, {action: foo}]}", "unknown action 'foo'"},
+		{"{action: macro, steps: [{action: key, keys: a}]}", "the steps of a macro must be strings"},
+		{"{action: tap-dance, taps: [a], timeout: 200}", "action requires at least two taps or a hold binding"},
+		{"[a, b]", "a binding must be either a string or a mapping"},
+	}
+	for _, test := range tests {
+		assertParseError(t, `
+layers:
+- name: initial
+  bindings:
+    a: `+test.binding+`
+`, test.expected)
+	}
+}
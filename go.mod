@@ -8,7 +8,7 @@ require (
 	github.com/jbensmann/uinput v1.7.1-0.20250425073443-7bb7a032d907
 	github.com/jessevdk/go-flags v1.6.1
 	github.com/sirupsen/logrus v1.9.3
-	gopkg.in/yaml.v2 v2.4.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require golang.org/x/sys v0.25.0 // indirect
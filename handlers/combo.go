@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"slices"
 	"sync"
 	"time"
 
@@ -24,14 +25,19 @@ type ComboHandler struct {
 
 	comboTime int64
 
-	// store all incoming events in a queue first, as in the TapHoldHandler (it is never greater than 2 in this case)
+	// store all incoming events in a queue first, as in the TapHoldHandler
 	// use pointers so that we can edit the binding
 	eventInQueue    []*EventBinding
 	eventInPosition int
 
-	state         ComboState
-	comboTimer    *time.Timer
-	comboBindings map[uint16]config.Binding
+	state      ComboState
+	comboTimer *time.Timer
+	// all combos of the current layer that contain the first pressed key
+	combos []*layerCombo
+	// the keys that have been pressed while waiting, starting with the first one
+	pressedKeys []uint16
+	// the combo that is triggered in state ComboStateCombo
+	combo *layerCombo
 }
 
 func NewComboHandler(comboTime int64) *ComboHandler {
@@ -66,7 +72,7 @@ func (c *ComboHandler) comboTimeout() {
 		return
 	}
 	log.Debugf("ComboHandler: timed out")
-	c.state = ComboStateNoCombo
+	c.matchCombo()
 	c.comboResolved()
 	c.handleEvents()
 }
@@ -77,47 +83,42 @@ func (c *ComboHandler) handleNextEvent() {
 
 	log.Debugf("ComboHandler: handling Event: %+v", eventBinding)
 
-	comboBindings, isComboBinding := c.checkForComboBinding(*eventBinding)
-
-	if event.IsPress {
-		if isComboBinding {
-			if c.state != ComboStateWait {
+	if c.state != ComboStateWait {
+		if event.IsPress {
+			if combos, isComboBinding := c.checkForComboBinding(*eventBinding); isComboBinding {
 				log.Debugf("ComboHandler: waiting")
 				c.state = ComboStateWait
-				c.comboBindings = comboBindings
-
-				// set timeout to the defined timeout minus the already passed duration since the key press
-				timeout := time.Duration(c.comboTime)*time.Millisecond - time.Now().Sub(event.Time)
-				if timeout < 0 {
-					timeout = 0
-				}
-				c.comboTimer = time.AfterFunc(timeout, c.comboTimeout)
-			}
-		}
-	} else {
-		if c.state == ComboStateWait {
-			// don't execute combo if the first key is released
-			if c.eventInQueue[0].Event.Code == event.Code {
-				c.state = ComboStateNoCombo
+				c.combos = combos
+				c.pressedKeys = []uint16{event.Code}
+				c.startComboTimer()
 			}
 		}
-	}
-
-	// if another key is pressed, check if it is a combo
-	if c.state == ComboStateWait && event.Code != c.eventInQueue[0].Event.Code {
-		if event.IsPress {
-			if binding, ok := c.comboBindings[event.Code]; ok {
-				c.eventInQueue[0].Binding = binding
-				// the second key is consumed now
-				eventBinding.Binding = config.NopBinding{}
+	} else if event.IsPress {
+		// drop the incomplete combos whose timeout has passed when the key was pressed
+		elapsed := event.Time.Sub(c.eventInQueue[0].Event.Time)
+		c.combos = slices.DeleteFunc(c.combos, func(combo *layerCombo) bool {
+			return c.timeoutOf(combo) < elapsed && !isSubset(combo.Keys, c.pressedKeys)
+		})
 
+		// if another key is pressed, check if it can still be part of a combo
+		pressedKeys := append(slices.Clone(c.pressedKeys), event.Code)
+		if !c.canBeCompleted(pressedKeys) {
+			// the key is not part of a combo, so fall back to what has been pressed before
+			c.matchCombo()
+		} else {
+			c.pressedKeys = pressedKeys
+			// trigger immediately if no longer combo is possible
+			combo := c.longestCombo()
+			if combo != nil && len(combo.Keys) == len(c.pressedKeys) && !c.canBeExtended() {
 				c.state = ComboStateCombo
+				c.combo = combo
 			} else {
-				c.state = ComboStateNoCombo
+				c.startComboTimer()
 			}
-		} else {
-			c.state = ComboStateNoCombo
 		}
+	} else {
+		// any key release ends the combo
+		c.matchCombo()
 	}
 
 	if c.state == ComboStateNoCombo || c.state == ComboStateCombo {
@@ -135,7 +136,77 @@ func (c *ComboHandler) handleNextEvent() {
 	}
 }
 
-// / comboResolved must be called after the state changed to ComboStateCombo or ComboStateNoCombo.
+// startComboTimer (re)starts the comboTimer, so that it fires when all combos that wait for further keys
+// have timed out.
+func (c *ComboHandler) startComboTimer() {
+	if c.comboTimer != nil {
+		c.comboTimer.Stop()
+	}
+	var maxTimeout time.Duration
+	for _, combo := range c.combos {
+		if len(combo.Keys) > len(c.pressedKeys) && isSubset(c.pressedKeys, combo.Keys) {
+			maxTimeout = max(maxTimeout, c.timeoutOf(combo))
+		}
+	}
+
+	// set timeout to the defined timeout minus the already passed duration since the first key press
+	timeout := maxTimeout - time.Now().Sub(c.eventInQueue[0].Event.Time)
+	if timeout < 0 {
+		timeout = 0
+	}
+	c.comboTimer = time.AfterFunc(timeout, c.comboTimeout)
+}
+
+// timeoutOf returns the timeout of the given combo, which falls back to the global comboTime.
+func (c *ComboHandler) timeoutOf(combo *layerCombo) time.Duration {
+	if combo.TimeoutMs > 0 {
+		return time.Duration(combo.TimeoutMs) * time.Millisecond
+	}
+	return time.Duration(c.comboTime) * time.Millisecond
+}
+
+// matchCombo ends the waiting by choosing the longest combo that consists of already pressed keys only.
+func (c *ComboHandler) matchCombo() {
+	c.combo = c.longestCombo()
+	if c.combo != nil {
+		c.state = ComboStateCombo
+	} else {
+		c.state = ComboStateNoCombo
+	}
+}
+
+// longestCombo returns the longest combo whose keys are all pressed, or nil if there is none.
+func (c *ComboHandler) longestCombo() *layerCombo {
+	// the combos are sorted with the longest first
+	for _, combo := range c.combos {
+		if isSubset(combo.Keys, c.pressedKeys) {
+			return combo
+		}
+	}
+	return nil
+}
+
+// canBeCompleted checks if there is a combo that contains all the given keys.
+func (c *ComboHandler) canBeCompleted(keys []uint16) bool {
+	for _, combo := range c.combos {
+		if isSubset(keys, combo.Keys) {
+			return true
+		}
+	}
+	return false
+}
+
+// canBeExtended checks if there is a combo that contains all pressed keys plus at least one more.
+func (c *ComboHandler) canBeExtended() bool {
+	for _, combo := range c.combos {
+		if len(combo.Keys) > len(c.pressedKeys) && isSubset(c.pressedKeys, combo.Keys) {
+			return true
+		}
+	}
+	return false
+}
+
+// comboResolved must be called after the state changed to ComboStateCombo or ComboStateNoCombo.
 func (c *ComboHandler) comboResolved() {
 	// stop the comboTimer in case it has not fired yet
 	if c.comboTimer != nil {
@@ -143,37 +214,79 @@ func (c *ComboHandler) comboResolved() {
 		c.comboTimer = nil
 	}
 
-	// the first key in the queue is the one that triggered the combo
-	c.EventHandled(*c.eventInQueue[0])
-	c.eventInQueue = c.eventInQueue[1:]
-
 	if c.state == ComboStateNoCombo {
 		log.Debugf("ComboHandler: no combo")
 	} else {
-		log.Debugf("ComboHandler: combo triggered")
+		log.Debugf("ComboHandler: combo triggered: %v", c.combo.Keys)
+		// the first key in the queue is the one that triggered the combo, the other keys of the combo are consumed
+		first := c.eventInQueue[0]
+		first.Binding = c.combo.Binding
+		first.Layer = c.combo.layer
+		consumed := slices.DeleteFunc(slices.Clone(c.combo.Keys), func(k uint16) bool { return k == first.Event.Code })
+		for _, eventBinding := range c.eventInQueue[1:] {
+			if i := slices.Index(consumed, eventBinding.Event.Code); i >= 0 && eventBinding.Event.IsPress {
+				eventBinding.Binding = config.NopBinding{}
+				consumed = slices.Delete(consumed, i, i+1)
+			}
+		}
 	}
 
+	c.EventHandled(*c.eventInQueue[0])
+	c.eventInQueue = c.eventInQueue[1:]
+
 	c.state = ComboStateIdle
+	c.combos = nil
+	c.pressedKeys = nil
+	c.combo = nil
 
 	// start again from the beginning of the queue
 	c.eventInPosition = 0
 }
 
-// checkForComboBinding checks if the given eventBinding is mapped to a combo in the current layer, and has
+// TakeQueuedEvents removes all events that have not been forwarded yet and resets the handler.
+func (c *ComboHandler) TakeQueuedEvents() []EventBinding {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.comboTimer != nil {
+		c.comboTimer.Stop()
+		c.comboTimer = nil
+	}
+	events := make([]EventBinding, len(c.eventInQueue))
+	for i, eventBinding := range c.eventInQueue {
+		events[i] = *eventBinding
+	}
+	c.eventInQueue = nil
+	c.eventInPosition = 0
+	c.state = ComboStateIdle
+	c.combos = nil
+	c.pressedKeys = nil
+	c.combo = nil
+	return events
+}
+
+// checkForComboBinding checks if the given eventBinding is part of a combo in the current layer, and has
 // no other Binding attached to it.
-// If the check is positive, it returns a map with all combo bindings, where the key is the other event code of the combo.
+// If the check is positive, it returns all combos that contain the key, longest first.
 // Otherwise, it returns nil.
-func (c *ComboHandler) checkForComboBinding(eventBinding EventBinding) (map[uint16]config.Binding, bool) {
+func (c *ComboHandler) checkForComboBinding(eventBinding EventBinding) ([]*layerCombo, bool) {
 	if eventBinding.Binding != nil {
 		return nil, false
 	}
-	currentLayer := c.layerManager.CurrentLayer()
-	if comboBindings, ok := currentLayer.ComboBindings[eventBinding.Event.Code]; ok {
-		return comboBindings, true
-	}
-	return nil, false
+	combos := resolveCombos(c.layerManager, eventBinding.Event.Code)
+	return combos, len(combos) > 0
 }
 
 func (c *ComboHandler) EventHandled(eventBinding EventBinding) {
 	c.next.HandleEvent(eventBinding)
 }
+
+// isSubset checks if all keys of a are contained in b.
+func isSubset(a []uint16, b []uint16) bool {
+	for _, k := range a {
+		if !slices.Contains(b, k) {
+			return false
+		}
+	}
+	return true
+}
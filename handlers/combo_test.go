@@ -64,3 +64,116 @@ layers:
 	handler := func() EventHandler { return NewComboHandler(int64(10)) }
 	testHandler(t, handler, configStr, tests)
 }
+
+func TestComboMultipleKeys(t *testing.T) {
+	configStr := `
+layers:
+- name: 1
+  bindings:
+    a+b: x
+    a+b+c: y
+    d+e+f: z
+`
+	tests := [][]string{
+		{"Pa Pb Pc Ra Rb Rc", "Pa:Ky Pb:N Pc:N Ra Rb Rc"}, // triggered
+		{"Pc Pb Pa Rc Rb Ra", "Pc:Ky Pb:N Pa:N Rc Rb Ra"},
+		{"Pa Pc Pb Ra Rb Rc", "Pa:Ky Pc:N Pb:N Ra Rb Rc"},
+		{"Pd Pe Pf Rd Re Rf", "Pd:Kz Pe:N Pf:N Rd Re Rf"},
+		{"Pa Pb Ra Rb", "Pa:Kx Pb:N Ra Rb"},                // falls back to the shorter combo on release
+		{"Pa Pb 15 Pc Ra Rb Rc", "Pa:Kx Pb:N Pc Ra Rb Rc"}, // falls back to the shorter combo on timeout
+		{"Pa Pb Pd Ra Rb Rd", "Pa:Kx Pb:N Pd Ra Rb Rd"},    // falls back to the shorter combo on another key
+		{"Pa Pc Ra Rc", "Pa Pc Ra Rc"},                     // no shorter combo to fall back to
+		{"Pd Pe Rd Re", "Pd Pe Rd Re"},
+		{"Pd Pe 15 Pf Rd Re Rf", "Pd Pe Pf Rd Re Rf"},
+		{"Pa Pd Pe Pf Ra Rd Re Rf", "Pa Pd:Kz Pe:N Pf:N Ra Rd Re Rf"}, // another combo after an interrupted one
+	}
+	handler := func() EventHandler { return NewComboHandler(int64(10)) }
+	testHandler(t, handler, configStr, tests)
+}
+
+func TestComboTimes(t *testing.T) {
+	configStr := `
+layers:
+- name: 1
+  bindings:
+    a+b: x
+    c+d: y
+    c+d+e: z
+  comboTimes:
+    a+b: 30
+    c+d+e: 30
+- name: 2
+  comboTime: 30
+  bindings:
+    a+b: x
+`
+	tests := [][]string{
+		{"Pa 15 Pb Ra Rb", "Pa:Kx Pb:N Ra Rb"}, // per-combo timeout
+		{"Pa 40 Pb Ra Rb", "Pa Pb Ra Rb"},
+		{"Pc 15 Pd Rc Rd", "Pc Pd Rc Rd"}, // the global timeout still applies to the other combos
+		{"Pc Pd 15 Pe Rc Rd Re", "Pc:Kz Pd:N Pe:N Rc Rd Re"},
+		{"Pc Pd 40 Pe Rc Rd Re", "Pc:Ky Pd:N Pe Rc Rd Re"},
+		{"Pc 15 Pe Rc Re", "Pc Pe Rc Re"},
+		{"Pz:L2 Pa 15 Pb Ra Rb Rz", "Pz:L2 Pa:Kx Pb:N Ra Rb Rz"}, // per-layer timeout
+		{"Pz:L2 Pa 40 Pb Ra Rb Rz", "Pz:L2 Pa Pb Ra Rb Rz"},
+	}
+	handler := func() EventHandler { return NewComboHandler(int64(10)) }
+	testHandler(t, handler, configStr, tests)
+}
+
+func TestComboExtends(t *testing.T) {
+	configStr := `
+layers:
+- name: 1
+  bindings:
+    a+b: x
+    c+d: y
+- name: 2
+  extends: 1
+  bindings:
+    c+d: z
+`
+	tests := [][]string{
+		{"Pe:L2 Pa Pb Ra Rb Re", "Pe:L2 Pa:Kx Pb:N Ra Rb Re"}, // inherited
+		{"Pe:L2 Pc Pd Rc Rd Re", "Pe:L2 Pc:Kz Pd:N Rc Rd Re"}, // overridden
+	}
+	handler := func() EventHandler { return NewComboHandler(int64(10)) }
+	testHandler(t, handler, configStr, tests)
+}
+
+func TestComboTransparent(t *testing.T) {
+	configStr := `
+layers:
+- name: 1
+  bindings:
+    a+b: x
+- name: 2
+  bindings:
+    a+b: ~
+    c+d: ~
+`
+	tests := [][]string{
+		{"Pe:L2 Pa Pb Ra Rb Re", "Pe:L2 Pa:Kx Pb:N Ra Rb Re"},
+		{"Pe:L2 Pc Pd Rc Rd Re", "Pe:L2 Pc Pd Rc Rd Re"}, // not defined in the layer below
+	}
+	handler := func() EventHandler { return NewComboHandler(int64(10)) }
+	testHandler(t, handler, configStr, tests)
+}
+
+func TestComboReload(t *testing.T) {
+	configStr := `
+layers:
+- name: 1
+  bindings:
+    a+b: x
+`
+	tests := [][]string{
+		// the new handler completes the combo
+		{"Pa", "Pb Ra Rb", "Pa:Kx Pb:N Ra Rb"},
+		{"Pa", "30 Ra", "Pa Ra"},
+		{"Pa", "Pc Rc Ra", "Pa Pc Rc Ra"},
+		{"Pc Rc", "Pa Pb Ra Rb", "Pc Rc Pa:Kx Pb:N Ra Rb"},
+	}
+	handler := func() EventHandler { return NewComboHandler(20) }
+	testReload(t, handler, configStr, tests)
+}
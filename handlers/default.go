@@ -21,7 +21,7 @@ func (d *DefaultHandler) HandleEvent(eventBinding EventBinding) {
 	// resolve the Binding if it is a press and not bound yet
 	if event.IsPress && eventBinding.Binding == nil {
 		currentLayer := d.layerManager.CurrentLayer()
-		binding, _ := currentLayer.Bindings[event.Code]
+		binding, layer := resolveBinding(d.layerManager, event.Code)
 
 		// switch to first layer on escape, if not mapped to something else
 		baseLayer := d.layerManager.BaseLayer()
@@ -30,16 +30,17 @@ func (d *DefaultHandler) HandleEvent(eventBinding EventBinding) {
 		}
 
 		// use the wildcard Binding if no Binding is defined for the key
-		if binding == nil && currentLayer.WildcardBinding != nil {
-			binding = currentLayer.WildcardBinding
+		if binding == nil && layer.WildcardBinding != nil && !isTransparent(layer.WildcardBinding) {
+			binding = layer.WildcardBinding
 		}
 
 		// if there is no wildcard either and pass through is enabled, insert a KeyBinding
-		if binding == nil && currentLayer.PassThrough {
+		if binding == nil && layer.PassThrough {
 			binding = config.KeyBinding{KeyCombo: []uint16{event.Code}}
 		}
 
 		eventBinding.Binding = binding
+		eventBinding.Layer = layer
 	}
 
 	d.next.HandleEvent(eventBinding)
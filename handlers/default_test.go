@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"testing"
+)
+
+func TestDefault(t *testing.T) {
+	configStr := `
+layers:
+- name: 1
+  bindings:
+    a: x
+- name: 2
+  passThrough: false
+  bindings:
+    b: y
+`
+	tests := [][]string{
+		{"Pa Ra", "Pa:Kx Ra"},
+		{"Pc Rc", "Pc:Kc Rc"},    // pass through
+		{"Pa:Km Ra", "Pa:Km Ra"}, // event already mapped to a binding
+		{"Pd:L2 Pb Rb Rd", "Pd:L2 Pb:Ky Rb Rd"},
+		{"Pd:L2 Pa Ra Rd", "Pd:L2 Pa Ra Rd"}, // no pass through
+	}
+	handler := func() EventHandler { return NewDefaultHandler() }
+	testHandler(t, handler, configStr, tests)
+}
+
+func TestDefaultTransparent(t *testing.T) {
+	configStr := `
+layers:
+- name: 1
+  bindings:
+    a: x
+    b: y
+- name: 2
+  passThrough: false
+  bindings:
+    a: ~
+    c: z
+- name: 3
+  bindings:
+    c: ~
+    _: ~
+`
+	tests := [][]string{
+		{"Pd:L2 Pa Ra Rd", "Pd:L2 Pa:Kx Ra Rd"}, // resolved in the layer below
+		{"Pd:L2 Pb Rb Rd", "Pd:L2 Pb Rb Rd"},    // not transparent
+		{"Pd:L2 Pc Rc Rd", "Pd:L2 Pc:Kz Rc Rd"},
+		{"Pd:L2 Pe:L3 Pc Rc Re Rd", "Pd:L2 Pe:L3 Pc:Kz Rc Re Rd"}, // transparent over two layers
+		{"Pd:L2 Pe:L3 Pa Ra Re Rd", "Pd:L2 Pe:L3 Pa:Kx Ra Re Rd"},
+		{"Pd:L3 Pb Rb Rd", "Pd:L3 Pb:Ky Rb Rd"}, // transparent wildcard
+		{"Pd:L3 Pf Rf Rd", "Pd:L3 Pf:Kf Rf Rd"},
+	}
+	handler := func() EventHandler { return NewDefaultHandler() }
+	testHandler(t, handler, configStr, tests)
+}
+
+func TestDefaultKeyNames(t *testing.T) {
+	configStr := `
+keyAliases:
+  hyper: f13
+  mykey: 30
+layers:
+- name: 1
+  bindings:
+    KEY_A: KEY_X
+    B: LEFTCTRL
+    c: hyper
+    hyper: mykey
+    KEY_1: btn_left
+`
+	tests := [][]string{
+		{"Pa Ra", "Pa:Kx Ra"},
+		{"Pb Rb", "Pb:Kleftctrl Rb"},
+		{"Pc Rc", "Pc:Kf13 Rc"},
+		{"Pf13 Rf13", "Pf13:Ka Rf13"},
+		{"Pk1 Rk1", "Pk1:Kbtn_left Rk1"},
+	}
+	handler := func() EventHandler { return NewDefaultHandler() }
+	testHandler(t, handler, configStr, tests)
+}
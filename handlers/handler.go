@@ -1,6 +1,8 @@
 package handlers
 
 import (
+	"slices"
+
 	"github.com/jbensmann/mouseless/config"
 	"github.com/jbensmann/mouseless/keyboard"
 )
@@ -8,10 +10,14 @@ import (
 type EventBinding struct {
 	Event   keyboard.Event
 	Binding config.Binding
+	// the layer that the binding comes from, nil if it does not come from a layer
+	Layer *config.Layer
 }
 
 type LayerManager interface {
 	CurrentLayer() *config.Layer
+	// LayerStack returns all active layers, the last one is the current layer.
+	LayerStack() []*config.Layer
 	BaseLayer() *config.Layer
 	GetLayer(name string) (*config.Layer, bool)
 }
@@ -22,6 +28,13 @@ type EventHandler interface {
 	SetLayerManager(manager LayerManager)
 }
 
+// EventQueue is implemented by handlers that hold back events until they can be resolved.
+type EventQueue interface {
+	// TakeQueuedEvents removes all events that have not been forwarded yet and resets the handler. It is used to pass
+	// pending events on to a new handler when the config is reloaded.
+	TakeQueuedEvents() []EventBinding
+}
+
 type BaseHandler struct {
 	next         EventHandler
 	layerManager LayerManager
@@ -34,3 +47,91 @@ func (b *BaseHandler) SetNextHandler(handler EventHandler) {
 func (b *BaseHandler) SetLayerManager(manager LayerManager) {
 	b.layerManager = manager
 }
+
+// resolveBinding returns the binding of the given key in the current layer, where transparent bindings are resolved
+// through the layer stack. A layer whose wildcard binding is transparent is transparent for all unbound keys.
+// It also returns the layer in which the key has been resolved, which is the bottom layer if the key is transparent in
+// all layers. The returned binding is nil if the key is not bound in that layer.
+func resolveBinding(manager LayerManager, code uint16) (config.Binding, *config.Layer) {
+	stack := manager.LayerStack()
+	for i := len(stack) - 1; i >= 0; i-- {
+		layer := stack[i]
+		binding, ok := layer.Bindings[code]
+		if !ok {
+			if isTransparent(layer.WildcardBinding) {
+				continue
+			}
+			return nil, layer
+		}
+		if !isTransparent(binding) {
+			return binding, layer
+		}
+	}
+	return nil, stack[0]
+}
+
+// layerCombo is a combo together with the layer that its binding comes from.
+type layerCombo struct {
+	*config.Combo
+	layer *config.Layer
+}
+
+// layerSequence is a sequence together with the layer that its binding comes from.
+type layerSequence struct {
+	*config.Sequence
+	layer *config.Layer
+}
+
+// resolveCombos returns the combos of the current layer that contain the given key, where the combos with a
+// transparent binding get the binding of the same combo in the next layer of the layer stack.
+// Transparent combos that are not defined in any layer below are omitted.
+func resolveCombos(manager LayerManager, code uint16) []*layerCombo {
+	var combos []*layerCombo
+	stack := manager.LayerStack()
+	for _, c := range stack[len(stack)-1].ComboBindings {
+		if !slices.Contains(c.Keys, code) {
+			continue
+		}
+		combo := &layerCombo{Combo: c, layer: stack[len(stack)-1]}
+		for i := len(stack) - 2; i >= 0 && isTransparent(combo.Binding); i-- {
+			if c := config.FindCombo(stack[i].ComboBindings, combo.Keys); c != nil {
+				combo.Combo = &config.Combo{Keys: combo.Keys, Binding: c.Binding, TimeoutMs: combo.TimeoutMs}
+				combo.layer = stack[i]
+			}
+		}
+		if !isTransparent(combo.Binding) {
+			combos = append(combos, combo)
+		}
+	}
+	return combos
+}
+
+// resolveSequences returns the sequences of the current layer that start with the given keys, where the sequences
+// with a transparent binding get the binding of the same sequence in the next layer of the layer stack.
+// Transparent sequences that are not defined in any layer below are omitted.
+func resolveSequences(manager LayerManager, keys []uint16) []*layerSequence {
+	var sequences []*layerSequence
+	stack := manager.LayerStack()
+	for _, s := range stack[len(stack)-1].SequenceBindings {
+		if len(s.Keys) < len(keys) || !slices.Equal(s.Keys[:len(keys)], keys) {
+			continue
+		}
+		sequence := &layerSequence{Sequence: s, layer: stack[len(stack)-1]}
+		for i := len(stack) - 2; i >= 0 && isTransparent(sequence.Binding); i-- {
+			if s := config.FindSequence(stack[i].SequenceBindings, sequence.Keys); s != nil {
+				sequence.Sequence = &config.Sequence{Keys: sequence.Keys, Binding: s.Binding}
+				sequence.layer = stack[i]
+			}
+		}
+		if !isTransparent(sequence.Binding) {
+			sequences = append(sequences, sequence)
+		}
+	}
+	return sequences
+}
+
+// isTransparent checks if the given binding is a config.TransparentBinding.
+func isTransparent(binding config.Binding) bool {
+	_, ok := binding.(config.TransparentBinding)
+	return ok
+}
@@ -36,9 +36,9 @@ func (b *EventHandlerMock) HandleEvent(eventBinding EventBinding) {
 	if event.IsPress {
 		// check for toggle-layer binding
 		if binding, ok := binding.(config.ToggleLayerBinding); ok {
-			b.currentLayer = binding.Layer
 			b.toggleLayerKeys = append(b.toggleLayerKeys, event.Code)
 			b.toggleLayerPrevious = append(b.toggleLayerPrevious, b.currentLayer)
+			b.currentLayer = binding.Layer
 		}
 	} else {
 		// go back to the previous layer when toggleLayerKey is released
@@ -59,12 +59,33 @@ func (b *EventHandlerMock) BaseLayer() *config.Layer {
 }
 
 func (b *EventHandlerMock) CurrentLayer() *config.Layer {
+	return b.getLayer(b.currentLayer)
+}
+
+func (b *EventHandlerMock) LayerStack() []*config.Layer {
+	var stack []*config.Layer
+	for _, name := range b.toggleLayerPrevious {
+		stack = append(stack, b.getLayer(name))
+	}
+	return append(stack, b.CurrentLayer())
+}
+
+func (b *EventHandlerMock) getLayer(name string) *config.Layer {
 	for _, layer := range b.layers {
-		if layer.Name == b.currentLayer {
+		if layer.Name == name {
 			return layer
 		}
 	}
-	panic(fmt.Sprintf("non existing layer: %s", b.currentLayer))
+	panic(fmt.Sprintf("non existing layer: %s", name))
+}
+
+func (b *EventHandlerMock) GetLayer(name string) (*config.Layer, bool) {
+	for _, layer := range b.layers {
+		if layer.Name == name {
+			return layer, true
+		}
+	}
+	return nil, false
 }
 
 func (b *EventHandlerMock) SetNextHandler(_ EventHandler) {
@@ -93,6 +114,38 @@ func testCase(t *testing.T, handler EventHandler, conf *config.Config, events st
 	// feed events in
 	feedEventsIn(handler, events)
 
+	checkEventBindings(t, handlerMock.eventBindings, events, expectedEventBindings)
+}
+
+// testReload feeds the first events of each test into a handler, passes its queued events to a new handler like on a
+// config reload, and feeds the second events into the new handler. The event bindings forwarded by both handlers are
+// checked against the third string.
+func testReload(t *testing.T, handlerGenerator func() EventHandler, configStr string, tests [][]string) {
+	conf, err := config.ParseConfig([]byte(configStr))
+	if err != nil {
+		t.Fatal(fmt.Sprintf("Error parsing config: %v", err))
+	}
+	for _, test := range tests {
+		handlerMock := NewEventHandlerMock(conf)
+		oldHandler := handlerGenerator()
+		oldHandler.SetLayerManager(handlerMock)
+		oldHandler.SetNextHandler(handlerMock)
+		newHandler := handlerGenerator()
+		newHandler.SetLayerManager(handlerMock)
+		newHandler.SetNextHandler(handlerMock)
+
+		feedEventsIn(oldHandler, test[0])
+		for _, eventBinding := range oldHandler.(EventQueue).TakeQueuedEvents() {
+			newHandler.HandleEvent(eventBinding)
+		}
+		feedEventsIn(newHandler, test[1])
+
+		checkEventBindings(t, handlerMock.eventBindings, test[0]+" | "+test[1], test[2])
+	}
+}
+
+// checkEventBindings checks that the given event bindings match the expected ones, events is only used for messages.
+func checkEventBindings(t *testing.T, eventBindings []EventBinding, events string, expectedEventBindings string) {
 	// parse the expected event bindings
 	var expEventBindings []EventBinding
 	for _, exp := range strings.Split(expectedEventBindings, " ") {
@@ -100,11 +153,11 @@ func testCase(t *testing.T, handler EventHandler, conf *config.Config, events st
 	}
 
 	// check if we received the expected number of events
-	if len(expEventBindings) != len(handlerMock.eventBindings) {
+	if len(expEventBindings) != len(eventBindings) {
 		t.Errorf(
 			"expected %d event bindings but got %d for test case (%s, %s)",
 			len(expEventBindings),
-			len(handlerMock.eventBindings),
+			len(eventBindings),
 			events,
 			expectedEventBindings,
 		)
@@ -113,7 +166,7 @@ func testCase(t *testing.T, handler EventHandler, conf *config.Config, events st
 
 	// check if events and bindings are the same (except the time)
 	for i, expEventBinding := range expEventBindings {
-		actEventBinding := handlerMock.eventBindings[i]
+		actEventBinding := eventBindings[i]
 		if actEventBinding.Event.Code != expEventBinding.Event.Code || actEventBinding.Event.IsPress != expEventBinding.Event.IsPress ||
 			!reflect.DeepEqual(actEventBinding.Binding, expEventBinding.Binding) {
 			t.Errorf(
@@ -140,7 +193,7 @@ func convertEventToString(event keyboard.Event) string {
 }
 
 func feedEventsIn(handler EventHandler, events string) {
-	for _, s := range strings.Split(events, " ") {
+	for _, s := range strings.Fields(events) {
 		if s[0] == 'P' || s[0] == 'R' {
 			eventBinding := parseEventBinding(s)
 			handler.HandleEvent(eventBinding)
@@ -164,17 +217,40 @@ func parseEventBinding(eventBinding string) EventBinding {
 	}
 	var binding config.Binding
 	if len(split) > 1 {
-		b := split[1]
-		if b[0] == 'K' {
-			code, _ = config.GetKeyCode(b[1:])
-			binding = config.KeyBinding{KeyCombo: []uint16{code}}
-		} else if b[0] == 'L' {
-			binding = config.ToggleLayerBinding{Layer: b[1:]}
-		} else if b[0] == 'N' {
-			binding = config.NopBinding{}
-		} else {
-			panic(fmt.Sprintf("unexpected binding type %v", b[0]))
-		}
+		binding = parseBinding(split[1])
 	}
 	return EventBinding{Event: event, Binding: binding}
 }
+
+// parseBinding parses a binding of the form <type><argument>, multiple ones can be joined with + to a MultiBinding.
+func parseBinding(b string) config.Binding {
+	if parts := strings.Split(b, "+"); len(parts) > 1 {
+		multi := config.MultiBinding{}
+		for _, part := range parts {
+			multi.Bindings = append(multi.Bindings, parseBinding(part))
+		}
+		return multi
+	}
+	code, _ := config.GetKeyCode(b[1:])
+	switch b[0] {
+	case 'K':
+		return config.KeyBinding{KeyCombo: []uint16{code}}
+	case 'D':
+		return config.KeyPressBinding{Key: code}
+	case 'X':
+		return config.KeyReleaseBinding{Key: code}
+	case 'L':
+		return config.ToggleLayerBinding{Layer: b[1:]}
+	case 'N':
+		return config.NopBinding{}
+	case 'O':
+		return config.OneShotBinding{Keys: []uint16{code}}
+	case 'U':
+		return config.OneShotReleaseBinding{Keys: []uint16{code}}
+	case 'Q':
+		return config.OneShotLayerBinding{Layer: b[1:]}
+	case 'V':
+		return config.OneShotReleaseBinding{Layers: []string{b[1:]}}
+	}
+	panic(fmt.Sprintf("unexpected binding type %v", b[0]))
+}
@@ -0,0 +1,204 @@
+package handlers
+
+import (
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/jbensmann/mouseless/config"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// LeaderHandler listens for a leader sequence after a key with a leader binding has been pressed. The keys of the
+// sequence are swallowed, and the binding of the sequence is attached to its last key. It must come before the
+// DefaultHandler, since the keys of a sequence are not resolved in the current layer.
+type LeaderHandler struct {
+	BaseHandler
+
+	mu sync.Mutex
+
+	sequences     []*config.LeaderSequence
+	timeoutMs     int64
+	replayUnknown bool
+
+	isActive    bool
+	leaderEvent EventBinding
+	// the events after the leader key, without the release of the leader key itself
+	eventInQueue []EventBinding
+	typed        []uint16
+	// the keys whose press has been swallowed, so that their release is swallowed as well
+	swallowed   map[uint16]struct{}
+	leaderTimer *time.Timer
+}
+
+func NewLeaderHandler(sequences []*config.LeaderSequence, timeoutMs int64, replayUnknown bool) *LeaderHandler {
+	handler := LeaderHandler{
+		sequences:     sequences,
+		timeoutMs:     timeoutMs,
+		replayUnknown: replayUnknown,
+		swallowed:     make(map[uint16]struct{}),
+	}
+	return &handler
+}
+
+func (l *LeaderHandler) HandleEvent(eventBinding EventBinding) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	log.Debugf("LeaderHandler: handling Event: %+v", eventBinding)
+	event := eventBinding.Event
+
+	if !event.IsPress {
+		if _, ok := l.swallowed[event.Code]; ok {
+			delete(l.swallowed, event.Code)
+			if l.isActive && event.Code != l.leaderEvent.Event.Code {
+				l.eventInQueue = append(l.eventInQueue, eventBinding)
+			}
+			return
+		}
+		l.next.HandleEvent(eventBinding)
+		return
+	}
+
+	if !l.isActive {
+		layer, isLeaderBinding := l.checkForLeaderBinding(eventBinding)
+		if !isLeaderBinding {
+			l.next.HandleEvent(eventBinding)
+			return
+		}
+		log.Debugf("LeaderHandler: waiting for a leader sequence")
+		l.isActive = true
+		l.leaderEvent = eventBinding
+		// the leader sequences belong to the layer of the leader key
+		l.leaderEvent.Layer = layer
+		l.swallowed[event.Code] = struct{}{}
+		l.startTimer()
+		return
+	}
+
+	l.eventInQueue = append(l.eventInQueue, eventBinding)
+	l.typed = append(l.typed, event.Code)
+	l.swallowed[event.Code] = struct{}{}
+	l.checkSequence(false)
+}
+
+// checkSequence executes the sequence that matches the typed keys, unless there are longer ones that start with them
+// and the timeout has not passed yet.
+func (l *LeaderHandler) checkSequence(timedOut bool) {
+	var exact *config.LeaderSequence
+	hasLonger := false
+	for _, sequence := range l.sequences {
+		if slices.Equal(sequence.Keys, l.typed) {
+			exact = sequence
+		} else if len(sequence.Keys) > len(l.typed) && slices.Equal(sequence.Keys[:len(l.typed)], l.typed) {
+			hasLonger = true
+		}
+	}
+
+	if exact != nil && (!hasLonger || timedOut) {
+		l.executeSequence(exact)
+	} else if hasLonger && !timedOut {
+		l.startTimer()
+	} else {
+		l.unknownSequence()
+	}
+}
+
+// executeSequence forwards the last key of the sequence with the binding of the sequence.
+func (l *LeaderHandler) executeSequence(sequence *config.LeaderSequence) {
+	log.Debugf("LeaderHandler: executing the leader sequence %v", sequence.Keys)
+	lastKey := sequence.Keys[len(sequence.Keys)-1]
+	pressIndex := 0
+	for i := len(l.eventInQueue) - 1; i >= 0; i-- {
+		if l.eventInQueue[i].Event.IsPress && l.eventInQueue[i].Event.Code == lastKey {
+			pressIndex = i
+			break
+		}
+	}
+	press := l.eventInQueue[pressIndex]
+	press.Binding = sequence.Binding
+	press.Layer = l.leaderEvent.Layer
+	l.next.HandleEvent(press)
+
+	if _, ok := l.swallowed[lastKey]; ok {
+		// the key is still pressed, so its release is passed on
+		delete(l.swallowed, lastKey)
+	} else if releaseIndex := slices.IndexFunc(l.eventInQueue[pressIndex:], func(e EventBinding) bool {
+		return !e.Event.IsPress && e.Event.Code == lastKey
+	}); releaseIndex >= 0 {
+		l.next.HandleEvent(l.eventInQueue[pressIndex+releaseIndex])
+	}
+	l.reset()
+}
+
+// unknownSequence ends the leader sequence without a match, the typed keys are passed on if replayUnknown is set.
+func (l *LeaderHandler) unknownSequence() {
+	log.Debugf("LeaderHandler: unknown leader sequence %v", l.typed)
+	if l.replayUnknown {
+		for _, eventBinding := range l.eventInQueue {
+			l.next.HandleEvent(eventBinding)
+		}
+		// the releases of the replayed keys are not swallowed anymore
+		for _, code := range l.typed {
+			if code != l.leaderEvent.Event.Code {
+				delete(l.swallowed, code)
+			}
+		}
+	}
+	l.reset()
+}
+
+func (l *LeaderHandler) startTimer() {
+	if l.leaderTimer != nil {
+		l.leaderTimer.Stop()
+	}
+	l.leaderTimer = time.AfterFunc(time.Duration(l.timeoutMs)*time.Millisecond, l.leaderTimeout)
+}
+
+func (l *LeaderHandler) leaderTimeout() {
+	timer := l.leaderTimer
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	// check if the timer has been stopped while waiting for the lock
+	if timer == nil || timer != l.leaderTimer {
+		return
+	}
+	log.Debugf("LeaderHandler: leader sequence timed out")
+	l.checkSequence(true)
+}
+
+func (l *LeaderHandler) reset() {
+	if l.leaderTimer != nil {
+		l.leaderTimer.Stop()
+		l.leaderTimer = nil
+	}
+	l.isActive = false
+	l.eventInQueue = nil
+	l.typed = nil
+}
+
+// TakeQueuedEvents removes all events that have not been forwarded yet and resets the handler.
+func (l *LeaderHandler) TakeQueuedEvents() []EventBinding {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var events []EventBinding
+	if l.isActive {
+		events = append([]EventBinding{l.leaderEvent}, l.eventInQueue...)
+	}
+	l.reset()
+	l.swallowed = make(map[uint16]struct{})
+	return events
+}
+
+// checkForLeaderBinding checks if the given eventBinding is mapped to a LeaderBinding in the current layer or has
+// already a LeaderBinding attached to it. It also returns the layer that the binding comes from.
+func (l *LeaderHandler) checkForLeaderBinding(eventBinding EventBinding) (*config.Layer, bool) {
+	mappedBinding, layer := eventBinding.Binding, eventBinding.Layer
+	if mappedBinding == nil {
+		mappedBinding, layer = resolveBinding(l.layerManager, eventBinding.Event.Code)
+	}
+	_, ok := mappedBinding.(config.LeaderBinding)
+	return layer, ok
+}
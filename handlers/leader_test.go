@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"testing"
+
+	"github.com/jbensmann/mouseless/config"
+)
+
+func TestLeader(t *testing.T) {
+	configStr := `
+leaderSequences:
+  g: z
+  g s: x
+  w q: y
+layers:
+- name: 1
+  bindings:
+    a: leader
+`
+	tests := [][]string{
+		{"Pc Rc", "Pc Rc"}, // not triggered
+		{"Pa Ra Pw Rw Pq Rq", "Pq:Ky Rq"},
+		{"Pa Pw Pq Ra Rw Rq", "Pq:Ky Rq"},
+		{"Pa Ra Pw Rw Pq 30 Rq", "Pq:Ky Rq"},
+		{"Pa Ra Pg Rg Ps Rs", "Ps:Kx Rs"},
+		{"Pa Ra Pg Rg 30 Pc Rc", "Pg:Kz Rg Pc Rc"},       // a shorter sequence after the timeout
+		{"Pa Ra Pw Pe Re Rw Pc Rc", "Pc Rc"},             // unknown sequence
+		{"Pa Ra Pw Rw 30 Pq Rq", "Pq Rq"},                // timed out
+		{"Pc Pa Ra Rc Pw Pq Rw Rq", "Pc Rc Pq:Ky Rq"},    // release of a key pressed before
+		{"Pa Ra Pw Rw Pq Rq Pw Rw", "Pq:Ky Rq Pw Rw"},    // only once
+		{"Pa Ra Pa Ra Pw Rw Pq Rq", "Pw Rw Pq Rq"},       // the leader key is unknown in a sequence
+		{"Pa:Kb Ra Pw Rw Pq Rq", "Pa:Kb Ra Pw Rw Pq Rq"}, // event already mapped to a binding
+		{"Pa:N Ra Pw Rw Pq Rq", "Pa:N Ra Pw Rw Pq Rq"},
+	}
+	conf, _ := config.ParseConfig([]byte(configStr))
+	handler := func() EventHandler { return NewLeaderHandler(conf.LeaderSequences, 20, false) }
+	testHandler(t, handler, configStr, tests)
+}
+
+func TestLeaderReplayUnknown(t *testing.T) {
+	configStr := `
+leaderSequences:
+  w q: y
+layers:
+- name: 1
+  bindings:
+    a: leader
+`
+	tests := [][]string{
+		{"Pa Ra Pw Rw Pe Re", "Pw Rw Pe Re"},
+		{"Pa Ra Pw Pe Rw Re", "Pw Pe Rw Re"},
+		{"Pa Ra Pw Rw Pq Rq", "Pq:Ky Rq"},
+	}
+	conf, _ := config.ParseConfig([]byte(configStr))
+	handler := func() EventHandler { return NewLeaderHandler(conf.LeaderSequences, 20, true) }
+	testHandler(t, handler, configStr, tests)
+}
+
+func TestLeaderReload(t *testing.T) {
+	configStr := `
+leaderSequences:
+  w q: y
+layers:
+- name: 1
+  bindings:
+    a: leader
+`
+	tests := [][]string{
+		// the new handler continues with the sequence
+		{"Pa Ra", "Pw Rw Pq Rq", "Pq:Ky Rq"},
+		{"Pa Ra Pw", "Rw Pq Rq", "Pq:Ky Rq"},
+		{"Pa Ra Pw Rw", "30 Pq Rq", "Pq Rq"},
+		{"Pa Ra Pw Rw", "Pe Re Pq Rq", "Pq Rq"},
+		{"Pc Rc", "Pa Ra Pw Rw Pq Rq", "Pc Rc Pq:Ky Rq"},
+	}
+	conf, _ := config.ParseConfig([]byte(configStr))
+	handler := func() EventHandler { return NewLeaderHandler(conf.LeaderSequences, 20, false) }
+	testReload(t, handler, configStr, tests)
+}
@@ -1,7 +1,10 @@
 package handlers
 
 import (
+	"time"
+
 	"github.com/jbensmann/mouseless/config"
+	"github.com/jbensmann/mouseless/keyboard"
 
 	log "github.com/sirupsen/logrus"
 )
@@ -22,6 +25,8 @@ type ModLayerHandler struct {
 	layer            *config.Layer
 	originalLayer    *config.Layer
 	pressedLayerKeys map[uint16]struct{}
+	// the layer that the binding of the trigger key comes from
+	triggerLayer *config.Layer
 }
 
 func NewModLayerHandler() *ModLayerHandler {
@@ -35,12 +40,13 @@ func (t *ModLayerHandler) HandleEvent(eventBinding EventBinding) {
 	log.Debugf("ModLayerHandler: handling Event: %+v", eventBinding)
 	event := eventBinding.Event
 
-	modLayerBinding, isModLayerBinding := t.checkForModLayerBinding(eventBinding)
+	modLayerBinding, bindingLayer, isModLayerBinding := t.checkForModLayerBinding(eventBinding)
 
 	if event.IsPress {
 		if isModLayerBinding {
 			log.Debugf("ModLayerHandler: pressing modifier")
 			eventBinding.Binding = config.KeyBinding{KeyCombo: []uint16{modLayerBinding.ModKey}}
+			eventBinding.Layer = bindingLayer
 
 			// we don't allow two active mod bindings at the same time
 			if t.state == ModLayerStateIdle {
@@ -52,6 +58,7 @@ func (t *ModLayerHandler) HandleEvent(eventBinding EventBinding) {
 					t.layer = layer
 					t.modLayerBinding = &modLayerBinding
 					t.triggerKey = event.Code
+					t.triggerLayer = bindingLayer
 					t.pressedLayerKeys = make(map[uint16]struct{})
 					t.originalLayer = t.layerManager.CurrentLayer()
 				}
@@ -61,8 +68,9 @@ func (t *ModLayerHandler) HandleEvent(eventBinding EventBinding) {
 			// insert the binding, unless the layer has changed in the meantime
 			if t.state != ModLayerStateIdle && t.layerManager.CurrentLayer() == t.originalLayer {
 				binding, hasBinding := t.layer.Bindings[event.Code]
-				if hasBinding {
+				if hasBinding && !isTransparent(binding) {
 					t.pressedLayerKeys[event.Code] = struct{}{}
+					eventBinding.Layer = t.layer
 
 					if t.state == ModLayerStateModActive {
 						t.state = ModLayerStateLayerActive
@@ -99,6 +107,7 @@ func (t *ModLayerHandler) HandleEvent(eventBinding EventBinding) {
 				t.state = ModLayerStateIdle
 				t.modLayerBinding = nil
 				t.pressedLayerKeys = make(map[uint16]struct{})
+				t.triggerLayer = nil
 				t.layer = nil
 			}
 		}
@@ -107,16 +116,35 @@ func (t *ModLayerHandler) HandleEvent(eventBinding EventBinding) {
 	t.next.HandleEvent(eventBinding)
 }
 
+// TakeQueuedEvents resets the handler. No events are queued, but if only the modifier is active, the press of the
+// trigger key is returned, so that a new handler continues with the mod layer. If keys of the layer are held, the mod
+// layer ends, since passing them on would execute their bindings again.
+func (t *ModLayerHandler) TakeQueuedEvents() []EventBinding {
+	var events []EventBinding
+	if t.state == ModLayerStateModActive {
+		events = append(events, EventBinding{
+			Event:   keyboard.Event{Code: t.triggerKey, IsPress: true, Time: time.Now()},
+			Binding: *t.modLayerBinding,
+			Layer:   t.triggerLayer,
+		})
+	}
+	t.state = ModLayerStateIdle
+	t.modLayerBinding = nil
+	t.pressedLayerKeys = make(map[uint16]struct{})
+	t.triggerLayer = nil
+	t.layer = nil
+	return events
+}
+
 // checkForModLayerBinding checks if the given eventBinding is mapped to a ModLayerBinding in the current layer or has
-// already a ModLayerBinding attached to it.
-func (t *ModLayerHandler) checkForModLayerBinding(eventBinding EventBinding) (config.ModLayerBinding, bool) {
-	var mappedBinding config.Binding
-	if eventBinding.Binding != nil {
-		mappedBinding = eventBinding.Binding
-	} else {
-		currentLayer := t.layerManager.CurrentLayer()
-		mappedBinding, _ = currentLayer.Bindings[eventBinding.Event.Code]
+// already a ModLayerBinding attached to it. It also returns the layer that the binding comes from.
+func (t *ModLayerHandler) checkForModLayerBinding(
+	eventBinding EventBinding,
+) (config.ModLayerBinding, *config.Layer, bool) {
+	mappedBinding, layer := eventBinding.Binding, eventBinding.Layer
+	if mappedBinding == nil {
+		mappedBinding, layer = resolveBinding(t.layerManager, eventBinding.Event.Code)
 	}
 	modLayerBinding, ok := mappedBinding.(config.ModLayerBinding)
-	return modLayerBinding, ok
+	return modLayerBinding, layer, ok
 }
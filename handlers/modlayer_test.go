@@ -0,0 +1,38 @@
+package handlers
+
+import (
+	"testing"
+)
+
+const modLayerConfig = `
+layers:
+- name: 1
+  bindings:
+    a: mod-layer leftctrl 2
+- name: 2
+  bindings:
+    b: x
+`
+
+func TestModLayer(t *testing.T) {
+	tests := [][]string{
+		{"Pa Pc Rc Ra", "Pa:Kleftctrl Pc Rc Ra"},
+		{"Pa Pb Rb Ra", "Pa:Kleftctrl Pb:Xleftctrl+Kx Rb:Dleftctrl Ra"},
+		{"Pa Pb Pc Rc Rb Ra", "Pa:Kleftctrl Pb:Xleftctrl+Kx Pc Rc Rb:Dleftctrl Ra"},
+		{"Pb Rb", "Pb Rb"},
+	}
+	handler := func() EventHandler { return NewModLayerHandler() }
+	testHandler(t, handler, modLayerConfig, tests)
+}
+
+func TestModLayerReload(t *testing.T) {
+	tests := [][]string{
+		// the new handler continues with the mod layer
+		{"Pa", "Pb Rb Ra", "Pa:Kleftctrl Pa:Kleftctrl Pb:Xleftctrl+Kx Rb:Dleftctrl Ra"},
+		// the mod layer ends if a key of the layer is held
+		{"Pa Pb", "Rb Pb Rb Ra", "Pa:Kleftctrl Pb:Xleftctrl+Kx Rb Pb Rb Ra"},
+		{"Pa Ra", "Pb Rb", "Pa:Kleftctrl Ra Pb Rb"},
+	}
+	handler := func() EventHandler { return NewModLayerHandler() }
+	testReload(t, handler, modLayerConfig, tests)
+}
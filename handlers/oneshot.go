@@ -0,0 +1,190 @@
+package handlers
+
+import (
+	"slices"
+	"sync"
+	"time"
+
+	evdev "github.com/gvalkov/golang-evdev"
+	"github.com/jbensmann/mouseless/config"
+	"github.com/jbensmann/mouseless/keyboard"
+
+	log "github.com/sirupsen/logrus"
+)
+
+type OneShotState int
+
+const (
+	OneShotStateIdle OneShotState = iota
+	// the one-shot key is held down
+	OneShotStateHeld
+	// the one-shot key has been released without pressing another key, so it applies to the next key press
+	OneShotStateArmed
+)
+
+// OneShotHandler handles one-shot and one-shot-layer bindings, whose keys or layers are active like normal keys or
+// toggled layers while the one-shot key is held, or until the next key press if it is only tapped. It must come after
+// the DefaultHandler, since it relies on the bindings of the events being resolved.
+type OneShotHandler struct {
+	BaseHandler
+
+	mu sync.Mutex
+
+	timeoutMs      int64
+	cancelOnEscape bool
+
+	state      OneShotState
+	triggerKey uint16
+	keys       []uint16
+	layers     []string
+	// whether a key has been pressed while the one-shot key was held
+	used  bool
+	timer *time.Timer
+}
+
+func NewOneShotHandler(timeoutMs int64, cancelOnEscape bool) *OneShotHandler {
+	handler := OneShotHandler{
+		timeoutMs:      timeoutMs,
+		cancelOnEscape: cancelOnEscape,
+		state:          OneShotStateIdle,
+	}
+	return &handler
+}
+
+func (o *OneShotHandler) HandleEvent(eventBinding EventBinding) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	log.Debugf("OneShotHandler: handling Event: %+v", eventBinding)
+	event := eventBinding.Event
+
+	if event.IsPress {
+		if oneShotBinding, ok := eventBinding.Binding.(config.OneShotBinding); ok {
+			o.activate(event.Code)
+			for _, key := range oneShotBinding.Keys {
+				if !slices.Contains(o.keys, key) {
+					o.keys = append(o.keys, key)
+				}
+			}
+		} else if oneShotLayerBinding, ok := eventBinding.Binding.(config.OneShotLayerBinding); ok {
+			o.activate(event.Code)
+			o.layers = append(o.layers, oneShotLayerBinding.Layer)
+		} else if o.state == OneShotStateHeld {
+			if o.isConsumedBy(eventBinding.Binding) {
+				o.used = true
+			}
+		} else if o.state == OneShotStateArmed {
+			if o.cancelOnEscape && event.Code == evdev.KEY_ESC {
+				log.Debugf("OneShotHandler: cancelled by escape")
+				eventBinding.Binding = o.releaseBinding()
+				o.reset()
+			} else if o.isConsumedBy(eventBinding.Binding) {
+				log.Debugf("OneShotHandler: releasing after the next key press")
+				if eventBinding.Binding == nil {
+					eventBinding.Binding = o.releaseBinding()
+				} else {
+					eventBinding.Binding = config.MultiBinding{
+						Bindings: []config.Binding{eventBinding.Binding, o.releaseBinding()},
+					}
+				}
+				o.reset()
+			}
+		}
+	} else if o.state == OneShotStateHeld && event.Code == o.triggerKey {
+		if o.used {
+			// it has been used like a normal modifier
+			eventBinding.Binding = o.releaseBinding()
+			o.reset()
+		} else {
+			log.Debugf("OneShotHandler: armed for the next key press")
+			o.state = OneShotStateArmed
+			if o.timeoutMs > 0 {
+				o.timer = time.AfterFunc(time.Duration(o.timeoutMs)*time.Millisecond, o.oneShotTimeout)
+			}
+		}
+	}
+
+	o.next.HandleEvent(eventBinding)
+}
+
+// activate starts a one-shot binding that is triggered by the given key, a further one-shot binding is combined with
+// the active one.
+func (o *OneShotHandler) activate(triggerKey uint16) {
+	o.stopTimer()
+	o.state = OneShotStateHeld
+	o.triggerKey = triggerKey
+	o.used = false
+}
+
+func (o *OneShotHandler) oneShotTimeout() {
+	timer := o.timer
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	// check if the timer has been stopped while waiting for the lock
+	if timer == nil || timer != o.timer {
+		return
+	}
+	log.Debugf("OneShotHandler: timed out")
+	o.release()
+}
+
+// TakeQueuedEvents releases the keys and layers of an active one-shot binding, there are never any queued events.
+func (o *OneShotHandler) TakeQueuedEvents() []EventBinding {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.state != OneShotStateIdle {
+		o.release()
+	}
+	return nil
+}
+
+// release forwards an event that releases the keys and layers of the one-shot binding and resets the handler.
+func (o *OneShotHandler) release() {
+	o.next.HandleEvent(EventBinding{
+		Event:   keyboard.Event{Code: o.triggerKey, IsPress: true, Time: time.Now()},
+		Binding: o.releaseBinding(),
+	})
+	o.reset()
+}
+
+func (o *OneShotHandler) releaseBinding() config.Binding {
+	return config.OneShotReleaseBinding{Keys: o.keys, Layers: o.layers}
+}
+
+func (o *OneShotHandler) reset() {
+	o.stopTimer()
+	o.state = OneShotStateIdle
+	o.keys = nil
+	o.layers = nil
+	o.used = false
+}
+
+func (o *OneShotHandler) stopTimer() {
+	if o.timer != nil {
+		o.timer.Stop()
+		o.timer = nil
+	}
+}
+
+// isConsumedBy checks if the active one-shot binding is used up by a key press with the given binding. A key that is
+// not bound uses up a one-shot layer as well, since it has been resolved in it.
+func (o *OneShotHandler) isConsumedBy(binding config.Binding) bool {
+	return consumesOneShot(binding) || (binding == nil && len(o.layers) > 0)
+}
+
+// consumesOneShot checks if the given binding uses up an active one-shot binding, which is not the case for modifiers
+// and layer changes, so that these can be combined with it.
+func consumesOneShot(binding config.Binding) bool {
+	switch t := binding.(type) {
+	case nil, config.NopBinding, config.KeyPressBinding, config.KeyReleaseBinding:
+		return false
+	case config.LayerBinding, config.ToggleLayerBinding, config.PushLayerBinding, config.PopLayerBinding,
+		config.LayerBackBinding:
+		return false
+	case config.KeyBinding:
+		return slices.ContainsFunc(t.KeyCombo, func(k uint16) bool { return !config.IsModifierKey(k) })
+	case config.MultiBinding:
+		return slices.ContainsFunc(t.Bindings, consumesOneShot)
+	}
+	return true
+}
@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"testing"
+)
+
+func TestOneShot(t *testing.T) {
+	configStr := `
+layers:
+- name: 1
+  bindings:
+    a: one-shot leftshift
+- name: 2
+  bindings:
+    b: b
+`
+	tests := [][]string{
+		{"Pb:Kb Rb", "Pb:Kb Rb"}, // not triggered
+		{"Pa:Oleftshift Ra Pb:Kb Rb", "Pa:Oleftshift Ra Pb:Kb+Uleftshift Rb"},
+		{"Pa:Oleftshift Ra Pb:Kb Rb Pc:Kc Rc", "Pa:Oleftshift Ra Pb:Kb+Uleftshift Rb Pc:Kc Rc"}, // only the next key
+		{"Pa:Oleftshift Pb:Kb Rb Ra", "Pa:Oleftshift Pb:Kb Rb Ra:Uleftshift"},                   // held like a modifier
+		{"Pa:Oleftshift Pb:Kb Ra Rb", "Pa:Oleftshift Pb:Kb Ra:Uleftshift Rb"},
+		{"Pa:Oleftshift Ra Pc:Kleftctrl Pb:Kb Rb Rc", "Pa:Oleftshift Ra Pc:Kleftctrl Pb:Kb+Uleftshift Rb Rc"}, // modifier
+		{"Pa:Oleftshift Ra Pc:L2 Pb:Kb Rb Rc", "Pa:Oleftshift Ra Pc:L2 Pb:Kb+Uleftshift Rb Rc"},               // layer
+		{"Pa:Oleftshift Ra Pc Rc Pb:Kb Rb", "Pa:Oleftshift Ra Pc Rc Pb:Kb+Uleftshift Rb"},                     // unbound key
+		{"Pa:Oleftshift Ra Pesc:Kesc Resc", "Pa:Oleftshift Ra Pesc:Uleftshift Resc"},                          // cancelled
+	}
+	handler := func() EventHandler { return NewOneShotHandler(0, true) }
+	testHandler(t, handler, configStr, tests)
+}
+
+func TestOneShotTimeout(t *testing.T) {
+	configStr := `
+layers:
+- name: 1
+  bindings:
+    a: one-shot leftshift
+`
+	tests := [][]string{
+		{"Pa:Oleftshift Ra 10 Pb:Kb Rb", "Pa:Oleftshift Ra Pb:Kb+Uleftshift Rb"},
+		{"Pa:Oleftshift Ra 30 Pb:Kb Rb", "Pa:Oleftshift Ra Pa:Uleftshift Pb:Kb Rb"},
+		{"Pa:Oleftshift 30 Pb:Kb Rb Ra", "Pa:Oleftshift Pb:Kb Rb Ra:Uleftshift"}, // no timeout while held
+	}
+	handler := func() EventHandler { return NewOneShotHandler(20, true) }
+	testHandler(t, handler, configStr, tests)
+}
+
+func TestOneShotNoCancelOnEscape(t *testing.T) {
+	configStr := `
+layers:
+- name: 1
+  bindings:
+    a: one-shot leftshift
+`
+	tests := [][]string{
+		{"Pa:Oleftshift Ra Pesc:Kesc Resc", "Pa:Oleftshift Ra Pesc:Kesc+Uleftshift Resc"},
+	}
+	handler := func() EventHandler { return NewOneShotHandler(0, false) }
+	testHandler(t, handler, configStr, tests)
+}
+
+func TestOneShotLayer(t *testing.T) {
+	configStr := `
+layers:
+- name: 1
+  bindings:
+    a: one-shot-layer 2
+    s: one-shot leftshift
+- name: 2
+  bindings:
+    b: b
+    c: toggle-layer 3
+- name: 3
+  bindings:
+    d: d
+`
+	tests := [][]string{
+		{"Pa:Q2 Ra Pb:Kb Rb", "Pa:Q2 Ra Pb:Kb+V2 Rb"},
+		{"Pa:Q2 Ra Pb:Kb Rb Pb:Kb Rb", "Pa:Q2 Ra Pb:Kb+V2 Rb Pb:Kb Rb"}, // only the next key
+		{"Pa:Q2 Pb:Kb Rb Ra", "Pa:Q2 Pb:Kb Rb Ra:V2"},                   // held like toggle-layer
+		{"Pa:Q2 Ra Pe Re", "Pa:Q2 Ra Pe:V2 Re"},                         // unbound key
+		{"Pa:Q2 Ra Pc:L3 Pd:Kd Rd Rc", "Pa:Q2 Ra Pc:L3 Pd:Kd+V2 Rd Rc"}, // layer change
+		{"Pa:Q2 Ra Pesc:Kesc Resc", "Pa:Q2 Ra Pesc:V2 Resc"},            // cancelled
+	}
+	handler := func() EventHandler { return NewOneShotHandler(0, true) }
+	testHandler(t, handler, configStr, tests)
+}
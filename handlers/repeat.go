@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"sync"
+	"time"
+
+	"github.com/jbensmann/mouseless/config"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// RepeatHandler repeats the bindings of held keys in layers where this is enabled, e.g. to auto-click with a mouse
+// button. A repetition releases the binding and executes it again. The repetitions are either timed with the given
+// delay and rate, or triggered by the repeat events of the keyboard if the rate is 0. Only the last pressed key is
+// repeated, like the keyboard does it. It must come last, since it relies on the bindings of the events and the layers
+// they come from being resolved.
+type RepeatHandler struct {
+	BaseHandler
+
+	mu sync.Mutex
+
+	delayMs    int64
+	intervalMs int64
+
+	// the press whose binding is repeated, nil if no key is repeated
+	repeated    *EventBinding
+	repeatTimer *time.Timer
+}
+
+func NewRepeatHandler(delayMs int64, rate float64) *RepeatHandler {
+	handler := RepeatHandler{
+		delayMs: delayMs,
+	}
+	if rate > 0 {
+		handler.intervalMs = max(int64(1000/rate), 1)
+	}
+	return &handler
+}
+
+func (r *RepeatHandler) HandleEvent(eventBinding EventBinding) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	log.Debugf("RepeatHandler: handling Event: %+v", eventBinding)
+	event := eventBinding.Event
+
+	if event.IsPress {
+		// another key press ends the repetition
+		r.stop()
+		if binding := r.repeatableBinding(eventBinding); binding != nil {
+			r.repeated = &EventBinding{Event: event, Binding: binding}
+			if r.intervalMs > 0 {
+				r.startTimer(r.delayMs)
+			}
+		}
+	} else if r.repeated != nil && event.Code == r.repeated.Event.Code {
+		r.stop()
+	}
+
+	r.next.HandleEvent(eventBinding)
+}
+
+// HandleRepeatEvent handles a repeat event of the keyboard, which repeats the binding of the held key unless the
+// repetitions are timed by the handler itself.
+func (r *RepeatHandler) HandleRepeatEvent(eventBinding EventBinding) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.intervalMs == 0 && r.repeated != nil && eventBinding.Event.Code == r.repeated.Event.Code {
+		r.repeat()
+	}
+}
+
+func (r *RepeatHandler) startTimer(delayMs int64) {
+	r.repeatTimer = time.AfterFunc(time.Duration(delayMs)*time.Millisecond, r.repeatTimeout)
+}
+
+func (r *RepeatHandler) repeatTimeout() {
+	timer := r.repeatTimer
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// check if the timer has been stopped while waiting for the lock
+	if timer == nil || timer != r.repeatTimer {
+		return
+	}
+	r.repeat()
+	r.startTimer(r.intervalMs)
+}
+
+// repeat releases the repeated binding and executes it again.
+func (r *RepeatHandler) repeat() {
+	log.Debugf("RepeatHandler: repeating %+v", r.repeated.Binding)
+	release := *r.repeated
+	release.Event.IsPress = false
+	release.Binding = nil
+	press := *r.repeated
+	press.Event.Time = time.Now()
+	release.Event.Time = press.Event.Time
+	r.next.HandleEvent(release)
+	r.next.HandleEvent(press)
+}
+
+func (r *RepeatHandler) stop() {
+	if r.repeatTimer != nil {
+		r.repeatTimer.Stop()
+		r.repeatTimer = nil
+	}
+	r.repeated = nil
+}
+
+// TakeQueuedEvents ends the repetition of a held key, there are never any queued events.
+func (r *RepeatHandler) TakeQueuedEvents() []EventBinding {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.stop()
+	return nil
+}
+
+// repeatableBinding returns the part of the binding of the given press that is repeated, or nil if the key is not
+// repeated in the layer its binding comes from, as resolved by the handlers before.
+func (r *RepeatHandler) repeatableBinding(eventBinding EventBinding) config.Binding {
+	if eventBinding.Binding == nil || eventBinding.Layer == nil {
+		return nil
+	}
+	if !eventBinding.Layer.IsRepeated(eventBinding.Event.Code) {
+		return nil
+	}
+	return config.RepeatableBinding(eventBinding.Binding, eventBinding.Event.Code)
+}
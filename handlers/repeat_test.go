@@ -0,0 +1,152 @@
+package handlers
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/jbensmann/mouseless/config"
+)
+
+// layerRepeatHandler is a RepeatHandler whose events get the current layer attached to their bindings, like the
+// handlers before it do when they resolve a binding.
+type layerRepeatHandler struct {
+	*RepeatHandler
+}
+
+func newLayerRepeatHandler(delayMs int64, rate float64) EventHandler {
+	return layerRepeatHandler{NewRepeatHandler(delayMs, rate)}
+}
+
+func (r layerRepeatHandler) HandleEvent(eventBinding EventBinding) {
+	if eventBinding.Binding != nil {
+		eventBinding.Layer = r.layerManager.CurrentLayer()
+	}
+	r.RepeatHandler.HandleEvent(eventBinding)
+}
+
+func TestRepeat(t *testing.T) {
+	configStr := `
+layers:
+- name: 1
+  repeatKeys: [f]
+  bindings:
+    f: b
+    g: b
+`
+	tests := [][]string{
+		{"Pf:Kb 30 Rf", "Pf:Kb Rf Pf:Kb Rf"},
+		{"Pf:Kb 10 Rf", "Pf:Kb Rf"},              // released before the delay
+		{"Pg:Kb 30 Rg", "Pg:Kb Rg"},              // not repeated in the layer
+		{"Pf 30 Rf", "Pf Rf"},                    // no binding
+		{"Pf:N 30 Rf", "Pf:N Rf"},                // nothing to repeat
+		{"Pf:Kb+N 30 Rf", "Pf:Kb+N Rf Pf:Kb Rf"}, // only the repeatable part
+		{"Pf:Kb 10 Pc 30 Rf Rc", "Pf:Kb Pc Rf Rc"},
+	}
+	handler := func() EventHandler { return newLayerRepeatHandler(20, 50) }
+	testHandler(t, handler, configStr, tests)
+}
+
+func TestRepeatLayer(t *testing.T) {
+	configStr := `
+layers:
+- name: 1
+  repeat: true
+  bindings:
+    f: b
+`
+	tests := [][]string{
+		{"Pc:Kb 30 Rc", "Pc:Kb Rc Pc:Kb Rc"},
+		{"Pf:Kb 50 Rf", "Pf:Kb Rf Pf:Kb Rf Pf:Kb Rf"},
+	}
+	handler := func() EventHandler { return newLayerRepeatHandler(20, 50) }
+	testHandler(t, handler, configStr, tests)
+}
+
+func TestRepeatEvents(t *testing.T) {
+	configStr := `
+layers:
+- name: 1
+  repeatKeys: [f]
+  bindings:
+    f: b
+`
+	conf, err := config.ParseConfig([]byte(configStr))
+	if err != nil {
+		t.Fatal(err)
+	}
+	handlerMock := NewEventHandlerMock(conf)
+	handler := NewRepeatHandler(20, 0)
+	handler.SetLayerManager(handlerMock)
+	handler.SetNextHandler(handlerMock)
+
+	handler.HandleRepeatEvent(parseEventBinding("Pf"))
+	press := parseEventBinding("Pf:Kb")
+	press.Layer = conf.Layers[0]
+	handler.HandleEvent(press)
+	feedEventsIn(handler, "30")
+	handler.HandleRepeatEvent(parseEventBinding("Pc"))
+	handler.HandleRepeatEvent(parseEventBinding("Pf"))
+	feedEventsIn(handler, "Rf")
+	handler.HandleRepeatEvent(parseEventBinding("Pf"))
+
+	var events []string
+	for _, eventBinding := range handlerMock.eventBindings {
+		events = append(events, convertEventToString(eventBinding.Event))
+	}
+	if expected := "Pf Rf Pf Rf"; strings.Join(events, " ") != expected {
+		t.Errorf("expected the events (%s) but got (%s)", expected, strings.Join(events, " "))
+	}
+}
+
+func TestRepeatReload(t *testing.T) {
+	configStr := `
+layers:
+- name: 1
+  repeatKeys: [f]
+  bindings:
+    f: b
+`
+	tests := [][]string{
+		// a held key is not repeated anymore after the reload
+		{"Pf:Kb", "60 Rf", "Pf:Kb Rf"},
+		{"Pf:Kb 30", "30 Rf", "Pf:Kb Rf Pf:Kb Rf"},
+		// keys pressed afterward are repeated by the new handler
+		{"Pc Rc", "Pf:Kb 30 Rf", "Pc Rc Pf:Kb Rf Pf:Kb Rf"},
+	}
+	handler := func() EventHandler { return newLayerRepeatHandler(20, 50) }
+	testReload(t, handler, configStr, tests)
+}
+
+func TestRepeatResolvedLayer(t *testing.T) {
+	configStr := `
+layers:
+- name: 1
+  repeat: true
+  bindings:
+    f>d: b
+    t: toggle-layer 2
+- name: 2
+  bindings:
+    f>d: ~
+    d: c
+`
+	conf, err := config.ParseConfig([]byte(configStr))
+	if err != nil {
+		t.Fatal(err)
+	}
+	handlerMock := NewEventHandlerMock(conf)
+	chain := []EventHandler{NewSequenceHandler(200), NewDefaultHandler(), NewRepeatHandler(20, 50)}
+	for i, handler := range chain {
+		handler.SetLayerManager(handlerMock)
+		if i < len(chain)-1 {
+			handler.SetNextHandler(chain[i+1])
+		} else {
+			handler.SetNextHandler(handlerMock)
+		}
+	}
+
+	// the binding of the sequence comes from the repeated layer below, not from the layer of its last key
+	events := "Pt Pf Rf Pd 30 Rd"
+	feedEventsIn(chain[0], events)
+	checkEventBindings(t, handlerMock.eventBindings, events, "Pt:L2 Pd:Kb Rd Pd:Kb Rd")
+}
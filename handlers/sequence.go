@@ -0,0 +1,170 @@
+package handlers
+
+import (
+	"slices"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// SequenceHandler handles sequence bindings like j>k, whose keys are typed one after another. A key that can start a
+// sequence is held back until it is clear whether the sequence is typed, i.e. until the next key is pressed or the
+// sequence time has passed. It must come before the DefaultHandler, since only keys without a binding are considered.
+type SequenceHandler struct {
+	BaseHandler
+
+	mu sync.Mutex
+
+	sequenceTime int64
+
+	eventInQueue []EventBinding
+	// the keys of the sequence that have been typed so far
+	typed []uint16
+	// the sequences that start with the typed keys
+	candidates []*layerSequence
+	// the keys of a triggered sequence that are still pressed, their release is swallowed
+	swallowed     map[uint16]struct{}
+	sequenceTimer *time.Timer
+}
+
+func NewSequenceHandler(sequenceTime int64) *SequenceHandler {
+	handler := SequenceHandler{
+		sequenceTime: sequenceTime,
+		swallowed:    make(map[uint16]struct{}),
+	}
+	return &handler
+}
+
+func (s *SequenceHandler) HandleEvent(eventBinding EventBinding) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.handleEvent(eventBinding)
+}
+
+func (s *SequenceHandler) handleEvent(eventBinding EventBinding) {
+	log.Debugf("SequenceHandler: handling Event: %+v", eventBinding)
+	event := eventBinding.Event
+
+	if !event.IsPress {
+		if _, ok := s.swallowed[event.Code]; ok {
+			delete(s.swallowed, event.Code)
+		} else if len(s.typed) > 0 {
+			// keep the order of the events
+			s.eventInQueue = append(s.eventInQueue, eventBinding)
+		} else {
+			s.next.HandleEvent(eventBinding)
+		}
+		return
+	}
+
+	// only keys that are not bound yet can be part of a sequence
+	var candidates []*layerSequence
+	if eventBinding.Binding == nil {
+		candidates = resolveSequences(s.layerManager, append(slices.Clone(s.typed), event.Code))
+	}
+
+	if len(candidates) == 0 {
+		if len(s.typed) == 0 {
+			s.next.HandleEvent(eventBinding)
+			return
+		}
+		// the key interrupts the sequence, it is handled after the sequence has been resolved
+		s.resolveSequence()
+		s.handleEvent(eventBinding)
+		return
+	}
+
+	s.eventInQueue = append(s.eventInQueue, eventBinding)
+	s.typed = append(s.typed, event.Code)
+	s.candidates = candidates
+	if len(candidates) == 1 && len(candidates[0].Keys) == len(s.typed) {
+		// there is no longer sequence to wait for
+		s.resolveSequence()
+		return
+	}
+	if s.sequenceTimer != nil {
+		s.sequenceTimer.Stop()
+	}
+	s.sequenceTimer = time.AfterFunc(time.Duration(s.sequenceTime)*time.Millisecond, s.sequenceTimeout)
+}
+
+func (s *SequenceHandler) sequenceTimeout() {
+	timer := s.sequenceTimer
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// check if the timer has been stopped while waiting for the lock
+	if timer == nil || timer != s.sequenceTimer {
+		return
+	}
+	log.Debugf("SequenceHandler: sequence timed out")
+	s.resolveSequence()
+}
+
+// resolveSequence triggers the sequence that matches the typed keys, or forwards the held back events if there is none.
+func (s *SequenceHandler) resolveSequence() {
+	var sequence *layerSequence
+	if i := slices.IndexFunc(s.candidates, func(c *layerSequence) bool { return slices.Equal(c.Keys, s.typed) }); i >= 0 {
+		sequence = s.candidates[i]
+	}
+	events := s.eventInQueue
+	s.reset()
+
+	if sequence == nil {
+		log.Debugf("SequenceHandler: no sequence matched")
+		for _, eventBinding := range events {
+			s.next.HandleEvent(eventBinding)
+		}
+		return
+	}
+
+	log.Debugf("SequenceHandler: sequence %v triggered", sequence.Keys)
+	// the binding is attached to the press of the last key, the other keys of the sequence are swallowed
+	lastPress := 0
+	for i, eventBinding := range events {
+		if eventBinding.Event.IsPress {
+			lastPress = i
+		}
+	}
+	pressed := make(map[uint16]struct{})
+	for i, eventBinding := range events {
+		event := eventBinding.Event
+		if i == lastPress {
+			eventBinding.Binding = sequence.Binding
+			eventBinding.Layer = sequence.layer
+			s.next.HandleEvent(eventBinding)
+		} else if event.IsPress {
+			pressed[event.Code] = struct{}{}
+		} else if _, ok := pressed[event.Code]; ok {
+			delete(pressed, event.Code)
+		} else {
+			// the release of the last key or of a key that has been pressed before the sequence started
+			s.next.HandleEvent(eventBinding)
+		}
+	}
+	for code := range pressed {
+		s.swallowed[code] = struct{}{}
+	}
+}
+
+func (s *SequenceHandler) reset() {
+	if s.sequenceTimer != nil {
+		s.sequenceTimer.Stop()
+		s.sequenceTimer = nil
+	}
+	s.eventInQueue = nil
+	s.typed = nil
+	s.candidates = nil
+}
+
+// TakeQueuedEvents removes all events that have not been forwarded yet and resets the handler.
+func (s *SequenceHandler) TakeQueuedEvents() []EventBinding {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	events := s.eventInQueue
+	s.reset()
+	s.swallowed = make(map[uint16]struct{})
+	return events
+}
@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"testing"
+)
+
+func TestSequence(t *testing.T) {
+	configStr := `
+layers:
+- name: 1
+  bindings:
+    j>k: esc
+    j>k>l: x
+    w>q: y
+    f>f: z
+`
+	tests := [][]string{
+		{"Pc Rc", "Pc Rc"}, // not part of a sequence
+		{"Pw Pq Rw Rq", "Pq:Ky Rq"},
+		{"Pw Rw Pq Rq", "Pq:Ky Rq"},
+		{"Pw Rw Pc Rc", "Pw Rw Pc Rc"},    // interrupted
+		{"Pw Rw 30 Pq Rq", "Pw Rw Pq Rq"}, // timed out
+		{"Pw 30 Rw", "Pw Rw"},             // timed out while pressed
+		{"Pf Rf Pf Rf", "Pf:Kz Rf"},       // the same key twice
+		{"Pj Rj Pk Rk Pl Rl", "Pl:Kx Rl"}, // the longer sequence
+		{"Pj Rj Pk Rk 30", "Pk:Kesc Rk"},  // the shorter sequence after the timeout
+		{"Pj Rj Pk Rk Pc Rc", "Pk:Kesc Rk Pc Rc"},
+		{"Pj Pk 30 Rj Rk", "Pk:Kesc Rk"},        // the release of the first key is swallowed
+		{"Pc Pw Rc Pq Rw Rq", "Pc Rc Pq:Ky Rq"}, // release of a key pressed before
+		{"Pw:Kb Rw Pq Rq", "Pw:Kb Rw Pq Rq"},    // event already mapped to a binding
+		{"Pw Pq:N Rw Rq", "Pw Pq:N Rw Rq"},
+	}
+	handler := func() EventHandler { return NewSequenceHandler(20) }
+	testHandler(t, handler, configStr, tests)
+}
+
+func TestSequenceReload(t *testing.T) {
+	configStr := `
+layers:
+- name: 1
+  bindings:
+    w>q: y
+`
+	tests := [][]string{
+		// the new handler continues with the sequence
+		{"Pw Rw", "Pq Rq", "Pq:Ky Rq"},
+		{"Pw", "Rw Pq Rq", "Pq:Ky Rq"},
+		{"Pw Rw", "30 Pq Rq", "Pw Rw Pq Rq"},
+		{"Pw Rw", "Pc Rc", "Pw Rw Pc Rc"},
+		{"Pc Rc", "Pw Rw Pq Rq", "Pc Rc Pq:Ky Rq"},
+	}
+	handler := func() EventHandler { return NewSequenceHandler(20) }
+	testReload(t, handler, configStr, tests)
+}
@@ -0,0 +1,175 @@
+package handlers
+
+import (
+	"sync"
+	"time"
+
+	"github.com/jbensmann/mouseless/config"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// TapDanceHandler resolves tap-dance bindings by counting the taps of the key. It holds back all events until the
+// dance is decided, which happens when the timeout passes after the last press or release of the key, when another
+// key is pressed, or when the highest number of taps has been reached.
+type TapDanceHandler struct {
+	BaseHandler
+
+	mu sync.Mutex
+
+	eventInQueue []EventBinding
+
+	tapDanceBinding *config.TapDanceBinding
+	triggerKey      uint16
+	count           int
+	isPressed       bool
+	tapDanceTimer   *time.Timer
+}
+
+func NewTapDanceHandler() *TapDanceHandler {
+	return &TapDanceHandler{}
+}
+
+func (t *TapDanceHandler) HandleEvent(eventBinding EventBinding) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.handleEvent(eventBinding)
+}
+
+func (t *TapDanceHandler) handleEvent(eventBinding EventBinding) {
+	log.Debugf("TapDanceHandler: handling Event: %+v", eventBinding)
+	event := eventBinding.Event
+
+	if t.tapDanceBinding == nil {
+		tapDanceBinding, layer, isTapDanceBinding := t.checkForTapDanceBinding(eventBinding)
+		if !event.IsPress || !isTapDanceBinding {
+			t.next.HandleEvent(eventBinding)
+			return
+		}
+		log.Debugf("TapDanceHandler: starting tap dance")
+		t.tapDanceBinding = &tapDanceBinding
+		t.triggerKey = event.Code
+		t.count = 1
+		t.isPressed = true
+		// the binding of the taps is attached to this event when resolved
+		eventBinding.Layer = layer
+		t.eventInQueue = append(t.eventInQueue, eventBinding)
+		t.startTimer()
+		return
+	}
+
+	if event.Code != t.triggerKey {
+		t.eventInQueue = append(t.eventInQueue, eventBinding)
+		// another key interrupts the dance, the key itself is handled after it has been resolved
+		if event.IsPress {
+			t.resolveTapDance()
+		}
+		return
+	}
+
+	t.eventInQueue = append(t.eventInQueue, eventBinding)
+	if event.IsPress {
+		t.count++
+		t.isPressed = true
+		t.startTimer()
+	} else {
+		t.isPressed = false
+		if t.count >= t.tapDanceBinding.MaxTaps() {
+			// there is nothing to wait for
+			t.resolveTapDance()
+		} else {
+			t.startTimer()
+		}
+	}
+}
+
+func (t *TapDanceHandler) startTimer() {
+	if t.tapDanceTimer != nil {
+		t.tapDanceTimer.Stop()
+	}
+	t.tapDanceTimer = time.AfterFunc(time.Duration(t.tapDanceBinding.TimeoutMs)*time.Millisecond, t.tapDanceTimeout)
+}
+
+func (t *TapDanceHandler) tapDanceTimeout() {
+	timer := t.tapDanceTimer
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	// check if the timer has been stopped while waiting for the lock
+	if timer == nil || timer != t.tapDanceTimer {
+		return
+	}
+	log.Debugf("TapDanceHandler: tap dance timed out")
+	t.resolveTapDance()
+}
+
+// resolveTapDance forwards the binding for the current number of taps, which is the hold binding if the key is still
+// pressed, and then handles the remaining events of the queue.
+func (t *TapDanceHandler) resolveTapDance() {
+	if t.tapDanceTimer != nil {
+		t.tapDanceTimer.Stop()
+		t.tapDanceTimer = nil
+	}
+	binding := t.tapDanceBinding.Binding(t.count, t.isPressed)
+	log.Debugf("TapDanceHandler: resolved %d taps (held: %v) to %+v", t.count, t.isPressed, binding)
+	if binding == nil {
+		binding = config.NopBinding{}
+	}
+
+	// the presses and releases of the trigger key are merged into a single press (and release if not held anymore)
+	var lastRelease *EventBinding
+	var remaining []EventBinding
+	for i, eventBinding := range t.eventInQueue {
+		if eventBinding.Event.Code != t.triggerKey {
+			remaining = append(remaining, eventBinding)
+		} else if !eventBinding.Event.IsPress {
+			lastRelease = &t.eventInQueue[i]
+		}
+	}
+	press := t.eventInQueue[0]
+	press.Binding = binding
+	isPressed := t.isPressed
+
+	t.eventInQueue = nil
+	t.tapDanceBinding = nil
+	t.count = 0
+	t.isPressed = false
+
+	t.next.HandleEvent(press)
+	if !isPressed && lastRelease != nil {
+		t.next.HandleEvent(*lastRelease)
+	}
+	for _, eventBinding := range remaining {
+		t.handleEvent(eventBinding)
+	}
+}
+
+// TakeQueuedEvents removes all events that have not been forwarded yet and resets the handler.
+func (t *TapDanceHandler) TakeQueuedEvents() []EventBinding {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.tapDanceTimer != nil {
+		t.tapDanceTimer.Stop()
+		t.tapDanceTimer = nil
+	}
+	events := t.eventInQueue
+	t.eventInQueue = nil
+	t.tapDanceBinding = nil
+	t.count = 0
+	t.isPressed = false
+	return events
+}
+
+// checkForTapDanceBinding checks if the given eventBinding is mapped to a TapDanceBinding in the current layer or has
+// already a TapDanceBinding attached to it. It also returns the layer that the binding comes from.
+func (t *TapDanceHandler) checkForTapDanceBinding(
+	eventBinding EventBinding,
+) (config.TapDanceBinding, *config.Layer, bool) {
+	mappedBinding, layer := eventBinding.Binding, eventBinding.Layer
+	if mappedBinding == nil {
+		mappedBinding, layer = resolveBinding(t.layerManager, eventBinding.Event.Code)
+	}
+	tapDanceBinding, ok := mappedBinding.(config.TapDanceBinding)
+	return tapDanceBinding, layer, ok
+}
@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"testing"
+)
+
+func TestTapDance(t *testing.T) {
+	configStr := `
+layers:
+- name: 1
+  bindings:
+    a: tap-dance x ; y ; 20
+`
+	tests := [][]string{
+		{"Pc Rc", "Pc Rc"}, // not triggered
+		{"Pa Ra 30", "Pa:Kx Ra"},
+		{"Pa Ra Pa Ra", "Pa:Ky Ra"}, // the highest count is resolved immediately
+		{"Pa Ra 30 Pa Ra 30", "Pa:Kx Ra Pa:Kx Ra"},
+		{"Pa 30 Ra", "Pa:Kx Ra"},                // held without a hold binding
+		{"Pa Ra Pc Rc", "Pa:Kx Ra Pc Rc"},       // interrupted by another key
+		{"Pa Ra Pa Pc Rc Ra", "Pa:Ky Pc Rc Ra"}, // interrupted while pressed
+		{"Pc Pa Rc Ra 30", "Pc Pa:Kx Ra Rc"},    // release of a key pressed before
+		{"Pa:Km Ra", "Pa:Km Ra"},                // event already mapped to a binding
+	}
+	handler := func() EventHandler { return NewTapDanceHandler() }
+	testHandler(t, handler, configStr, tests)
+}
+
+func TestTapDanceHold(t *testing.T) {
+	configStr := `
+layers:
+- name: 1
+  bindings:
+    a: {action: tap-dance, taps: [x, y], holds: [~, toggle-layer 2], timeout: 20}
+    b: {action: tap-dance, taps: [x], holds: [toggle-layer 2], timeout: 20}
+    d: tap-dance x; y | toggle-layer 2; 20
+    e: tap-dance ~ | toggle-layer 2; 20
+- name: 2
+  bindings:
+    c: z
+`
+	tests := [][]string{
+		{"Pa Ra Pa 30 Ra", "Pa:L2 Ra"}, // tap then hold
+		{"Pa Ra Pa Ra", "Pa:Ky Ra"},
+		{"Pa 30 Ra", "Pa:Kx Ra"}, // no hold binding for a single press
+		{"Pb Rb", "Pb:Kx Rb"},
+		{"Pb 30 Pc Rc Rb", "Pb:L2 Pc Rc Rb"},
+		{"Pd Rd Pd 30 Rd", "Pd:L2 Rd"}, // the same in the string form
+		{"Pd Rd Pd Rd", "Pd:Ky Rd"},
+		{"Pd 30 Rd", "Pd:Kx Rd"},
+		{"Pe 30 Pc Rc Re", "Pe:L2 Pc Rc Re"},
+		{"Pe Re 30", "Pe:L2 Re"}, // the hold binding is used for a tap as well
+	}
+	handler := func() EventHandler { return NewTapDanceHandler() }
+	testHandler(t, handler, configStr, tests)
+}
+
+func TestTapDanceReload(t *testing.T) {
+	configStr := `
+layers:
+- name: 1
+  bindings:
+    a: tap-dance x; y | toggle-layer 2; 20
+- name: 2
+`
+	tests := [][]string{
+		// the new handler continues counting the taps
+		{"Pa Ra", "30", "Pa:Kx Ra"},
+		{"Pa Ra", "Pa Ra", "Pa:Ky Ra"},
+		{"Pa Ra Pa", "30 Ra", "Pa:L2 Ra"},
+		{"Pc Pa Rc", "Ra 30", "Pc Pa:Kx Ra Rc"},
+		// the old handler does not forward anything after a decision
+		{"Pa Ra 30", "Pc Rc", "Pa:Kx Ra Pc Rc"},
+	}
+	handler := func() EventHandler { return NewTapDanceHandler() }
+	testReload(t, handler, configStr, tests)
+}
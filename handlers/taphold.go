@@ -84,7 +84,7 @@ func (t *TapHoldHandler) handleNextEvent() {
 
 	log.Debugf("TapHoldHandler: handling Event: %+v", eventBinding)
 
-	tapHoldBinding, isTapHoldBinding := t.checkForTapHoldBinding(*eventBinding)
+	tapHoldBinding, layer, isTapHoldBinding := t.checkForTapHoldBinding(*eventBinding)
 
 	if event.IsPress {
 		// tapHold key pressed?
@@ -93,6 +93,8 @@ func (t *TapHoldHandler) handleNextEvent() {
 				log.Debugf("TapHoldHandler: activating holdBack")
 				t.state = TapHoldStateWait
 				t.tapHoldBinding = &tapHoldBinding
+				// the tap or hold binding is attached to this event when resolved
+				eventBinding.Layer = layer
 
 				// remember all pressed keys
 				t.holdBackStartIsPressed = make(map[uint16]struct{})
@@ -196,22 +198,42 @@ func (t *TapHoldHandler) resolveTapHold() {
 	t.eventInPosition = 0
 }
 
+// TakeQueuedEvents removes all events that have not been forwarded yet and resets the handler.
+func (t *TapHoldHandler) TakeQueuedEvents() []EventBinding {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.tapHoldTimer != nil {
+		t.tapHoldTimer.Stop()
+		t.tapHoldTimer = nil
+	}
+	events := make([]EventBinding, len(t.eventInQueue))
+	for i, eventBinding := range t.eventInQueue {
+		events[i] = *eventBinding
+	}
+	t.eventInQueue = nil
+	t.eventInPosition = 0
+	t.state = TapHoldStateIdle
+	t.tapHoldBinding = nil
+	t.holdBackStartIsPressed = make(map[uint16]struct{})
+	return events
+}
+
 // checkForTapHoldBinding checks if the given eventBinding is mapped to a TapHoldBinding in the current layer or has
 // already a TapHoldBinding attached to it.
-// If the check is positive, it returns the TapHoldBinding.
+// If the check is positive, it returns the TapHoldBinding and the layer it comes from.
 // Otherwise, it returns nil.
-func (t *TapHoldHandler) checkForTapHoldBinding(eventBinding EventBinding) (config.TapHoldBinding, bool) {
-	var mappedBinding config.Binding
-	if eventBinding.Binding != nil {
-		mappedBinding = eventBinding.Binding
-	} else {
-		currentLayer := t.layerManager.CurrentLayer()
-		mappedBinding, _ = currentLayer.Bindings[eventBinding.Event.Code]
+func (t *TapHoldHandler) checkForTapHoldBinding(
+	eventBinding EventBinding,
+) (config.TapHoldBinding, *config.Layer, bool) {
+	mappedBinding, layer := eventBinding.Binding, eventBinding.Layer
+	if mappedBinding == nil {
+		mappedBinding, layer = resolveBinding(t.layerManager, eventBinding.Event.Code)
 	}
 	if tapHoldBinding, ok := mappedBinding.(config.TapHoldBinding); ok {
-		return tapHoldBinding, true
+		return tapHoldBinding, layer, true
 	} else {
-		return config.TapHoldBinding{}, false
+		return config.TapHoldBinding{}, nil, false
 	}
 }
 
@@ -165,3 +165,67 @@ layers:
 	handler := func() EventHandler { return NewTapHoldHandler(int64(quickTapTime)) }
 	testHandler(t, handler, configStr, tests)
 }
+
+func TestTapHoldExtends(t *testing.T) {
+	configStr := `
+layers:
+- name: 1
+  bindings:
+    a: tap-hold a ; x ; 10
+    b: tap-hold b ; y ; 10
+- name: 2
+  extends: 1
+  bindings:
+    b: b
+- name: 3
+  extends: 2
+`
+	tests := [][]string{
+		{"Pc:L2 Pa 15 Ra Rc", "Pc:L2 Pa:Kx Ra Rc"}, // inherited
+		{"Pc:L2 Pb 15 Rb Rc", "Pc:L2 Pb Rb Rc"},    // overridden
+		{"Pc:L3 Pa 15 Ra Rc", "Pc:L3 Pa:Kx Ra Rc"}, // inherited over two levels
+		{"Pc:L3 Pb 15 Rb Rc", "Pc:L3 Pb Rb Rc"},
+	}
+	handler := func() EventHandler { return NewTapHoldHandler(int64(50)) }
+	testHandler(t, handler, configStr, tests)
+}
+
+func TestTapHoldStructured(t *testing.T) {
+	configStr := `
+layers:
+- name: 1
+  bindings:
+    a: {action: tap-hold, tap: a, hold: {action: toggle-layer, layer: 2}, timeout: 10}
+    b: {action: tap-hold-next, tap: b, hold: y, timeout: 10}
+- name: 2
+`
+	tests := [][]string{
+		{"Pa Ra", "Pa:Ka Ra"},
+		{"Pa 15 Ra", "Pa:L2 Ra"},
+		{"Pb Pc Rc Rb", "Pb:Ky Pc Rc Rb"},
+	}
+	handler := func() EventHandler { return NewTapHoldHandler(int64(50)) }
+	testHandler(t, handler, configStr, tests)
+}
+
+func TestTapHoldReload(t *testing.T) {
+	configStr := `
+layers:
+- name: 1
+  bindings:
+    a: tap-hold a ; x ; 20
+`
+	tests := [][]string{
+		// the pending tap-hold is decided by the new handler
+		{"Pa", "Ra", "Pa:Ka Ra"},
+		{"Pa", "30 Ra", "Pa:Kx Ra"},
+		{"Pa 10", "15 Ra", "Pa:Kx Ra"},
+		{"Pa Pb Rb", "30 Ra", "Pa:Kx Pb Rb Ra"},
+		{"Pc Pa Rc", "Ra", "Pc Rc Pa:Ka Ra"},
+		// the old handler does not forward anything after a decision
+		{"Pa 30", "Ra", "Pa:Kx Ra"},
+		{"Pc Rc", "30", "Pc Rc"},
+	}
+	handler := func() EventHandler { return NewTapHoldHandler(0) }
+	testReload(t, handler, configStr, tests)
+}
@@ -15,7 +15,11 @@ import (
 type Event struct {
 	Code    uint16
 	IsPress bool
-	Time    time.Time
+	// whether the event is a repeat event of the kernel for a held key, IsPress is true then as well
+	IsRepeat bool
+	Time     time.Time
+	// the device that the event has been read from, nil if it does not come from a device
+	Device *Device
 }
 
 type DeviceState int
@@ -77,7 +81,10 @@ func (d *Device) readKeyboard() {
 		}
 		for _, event := range events {
 			if event.Type == evdev.EV_KEY {
-				if event.Value == 0 || event.Value == 1 {
+				if event.Value == 2 {
+					// repeat events are not logged, since they are sent continuously while a key is held
+					d.eventChan <- Event{Code: event.Code, IsPress: true, IsRepeat: true, Time: time.Now(), Device: d}
+				} else if event.Value == 0 || event.Value == 1 {
 
 					codeAlias, exists := config.GetKeyAlias(event.Code)
 					if !exists {
@@ -94,6 +101,7 @@ func (d *Device) readKeyboard() {
 						Code:    event.Code,
 						IsPress: event.Value == 1,
 						Time:    time.Now(),
+						Device:  d,
 					}
 					d.eventChan <- e
 				}
@@ -127,6 +135,16 @@ func (d *Device) LastOpenError() string {
 	return d.lastOpenError
 }
 
+// Release ungrabs the device and stops reading from it.
+func (d *Device) Release() {
+	d.state = StateNotOpen
+	if err := d.device.Release(); err != nil {
+		log.Warnf("Failed to release device %s: %v", d, err)
+	}
+	// unblocks the reading goroutine
+	_ = d.device.File.Close()
+}
+
 // Disconnected shall be called when a device has been removed.
 func (d *Device) Disconnected() {
 	d.state = StateNotOpen
@@ -4,11 +4,14 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+	"os/signal"
 	"os/user"
 	"path/filepath"
 	"slices"
 	"sort"
 	"strings"
+	"sync"
+	"syscall"
 	"time"
 
 	"github.com/jbensmann/mouseless/actions"
@@ -25,6 +28,9 @@ import (
 
 const (
 	defaultConfigFile = ".config/mouseless/config.yaml"
+	// the time to wait after a change of the config file before reloading it, since editors often write a file in
+	// multiple steps
+	configReloadDelay = 200 * time.Millisecond
 )
 
 var (
@@ -35,27 +41,58 @@ var (
 	configDevicesExclude []string
 
 	keyboardDevices []*keyboard.Device
-	virtualMouse    *virtual.Mouse
-	virtualKeyboard *virtual.Keyboard
-
-	keyEventChannel     chan keyboard.Event
-	firstEventHandler   handlers.EventHandler
-	reloadConfigChannel chan struct{}
+	// protects keyboardDevices and the device specification, which are also changed by the device watcher
+	devicesMutex       sync.Mutex
+	virtualMouse       *virtual.Mouse
+	virtualKeyboard    *virtual.Keyboard
+	virtualDeviceNames []string
+
+	keyEventChannel chan keyboard.Event
+	// the first chain is used for all devices that do not belong to a profile
+	handlerChains []*handlerChain
+	// the chains that have been chosen for the devices, reset when the config is reloaded
+	deviceChains map[*keyboard.Device]*handlerChain
+	// the keys that are currently held on each device, only accessed by the main loop
+	devicePressedKeys    map[*keyboard.Device][]uint16
+	reloadConfigChannel  chan struct{}
+	removedDeviceChannel chan *keyboard.Device
+
+	// the config file and all its includes, which are watched for changes
+	configWatcher    *fsnotify.Watcher
+	configFiles      []string
+	configFilesMutex sync.Mutex
 )
 
+// handlerChain contains the handlers and the executor of a profile, which keep their own layer state.
+type handlerChain struct {
+	profile  *config.Profile // nil for the default chain
+	executor *actions.Executor
+	handlers []handlers.EventHandler
+	// the last handler, which also gets the repeat events of the keyboard
+	repeater *handlers.RepeatHandler
+}
+
 var opts struct {
 	Version             bool   `short:"v" long:"version" description:"Show the version"`
 	Debug               bool   `short:"d" long:"debug" description:"Show verbose debug information"`
 	ConfigFile          string `short:"c" long:"config" description:"Specify an alternative config file"`
 	ListKeyboardDevices bool   `short:"l" long:"list-devices" description:"List all detected keyboard devices"`
 	ListAllDevices      bool   `short:"L" long:"list-all-devices" description:"List all detected devices"`
+	ListKeys            bool   `long:"list-keys" description:"List the names and codes of all keys"`
+	Check               bool   `long:"check" description:"Validate the config file and exit (same as the check command)"`
 }
 
 func main() {
-	_, err := flags.Parse(&opts)
+	args, err := flags.Parse(&opts)
 	if err != nil {
 		os.Exit(1)
 	}
+	if len(args) == 1 && args[0] == "check" {
+		opts.Check = true
+	} else if len(args) > 0 {
+		fmt.Fprintf(os.Stderr, "Unknown command: %s\n", strings.Join(args, " "))
+		os.Exit(1)
+	}
 	if opts.Version {
 		fmt.Println(version)
 		os.Exit(0)
@@ -68,6 +105,10 @@ func main() {
 		printDevices(false)
 		os.Exit(0)
 	}
+	if opts.ListKeys {
+		printKeys()
+		os.Exit(0)
+	}
 
 	// init logging
 	log.SetOutput(os.Stdout)
@@ -88,6 +129,9 @@ func main() {
 		configFile = filepath.Join(u.HomeDir, defaultConfigFile)
 	}
 	log.Debugf("Using config file: %s", configFile)
+	if opts.Check {
+		checkConfig()
+	}
 	conf, err := config.ReadConfig(configFile)
 	if err != nil {
 		exitError("Failed to read the config file", err)
@@ -100,6 +144,8 @@ func main() {
 func run(conf *config.Config) {
 	keyEventChannel = make(chan keyboard.Event, 1000)
 	reloadConfigChannel = make(chan struct{}, 1)
+	removedDeviceChannel = make(chan *keyboard.Device, 10)
+	devicePressedKeys = make(map[*keyboard.Device][]uint16)
 
 	allDevices, err := evdev.ListInputDevices("/dev/input/event*")
 	if err != nil {
@@ -111,7 +157,9 @@ func run(conf *config.Config) {
 		instanceName = conf.InstanceName
 	}
 	mouseName := instanceName + " mouse"
+	mouseButtonsName := instanceName + " mouse buttons"
 	keyboardName := instanceName + " keyboard"
+	virtualDeviceNames = []string{mouseName, mouseButtonsName, keyboardName}
 
 	// check if another instance of mouse is already running
 	for _, device := range allDevices {
@@ -128,7 +176,7 @@ func run(conf *config.Config) {
 
 	var usedDevices []*evdev.InputDevice
 	for _, device := range allDevices {
-		if shallDeviceBeUsed(device) {
+		if shallDeviceBeUsed(device, configDevices, configDevicesExclude) {
 			usedDevices = append(usedDevices, device)
 		}
 	}
@@ -137,7 +185,7 @@ func run(conf *config.Config) {
 	}
 
 	// init virtual mouse and keyboard
-	virtualMouse, err = virtual.NewMouse(conf, mouseName)
+	virtualMouse, err = virtual.NewMouse(conf, mouseName, mouseButtonsName)
 	if err != nil {
 		exitError("Failed to init the virtual mouse", err)
 	}
@@ -174,22 +222,45 @@ func run(conf *config.Config) {
 	if err != nil {
 		exitError("Failed to watch for keyboard devices", err)
 	}
+	err = watchConfigFiles(conf.Files)
+	if err != nil {
+		log.Warnf("Failed to watch the config file, it will not be reloaded automatically: %v", err)
+	}
+	reloadOnSignal()
 
 	virtualMouse.StartLoop()
 	mainLoop()
 }
 
 func initHandlers(conf *config.Config) {
-	executor := actions.NewExecutor(conf, virtualKeyboard, virtualMouse, reloadConfigChannel)
+	handlerChains = []*handlerChain{newHandlerChain(conf, nil)}
+	for _, profile := range conf.Profiles {
+		handlerChains = append(handlerChains, newHandlerChain(conf, profile))
+	}
+	deviceChains = make(map[*keyboard.Device]*handlerChain)
+}
+
+// newHandlerChain creates the handlers for the given profile, or the default ones if the profile is nil.
+func newHandlerChain(conf *config.Config, profile *config.Profile) *handlerChain {
+	baseLayer := conf.Layers[0]
+	if profile != nil {
+		baseLayer = profile.InitialLayer
+	}
+	executor := actions.NewExecutor(conf, baseLayer, virtualKeyboard, virtualMouse, reloadConfigChannel)
+	repeater := handlers.NewRepeatHandler(int64(conf.RepeatDelay), conf.RepeatRate)
 
 	h := []handlers.EventHandler{
 		handlers.NewComboHandler(int64(conf.ComboTime)),
+		handlers.NewSequenceHandler(int64(conf.SequenceTime)),
 		handlers.NewModLayerHandler(),
+		handlers.NewTapDanceHandler(),
 		handlers.NewTapHoldHandler(int64(conf.QuickTapTime)),
+		handlers.NewLeaderHandler(conf.LeaderSequences, int64(conf.LeaderTimeout), conf.LeaderReplayUnknown),
 		handlers.NewDefaultHandler(),
+		handlers.NewOneShotHandler(int64(conf.OneShotTimeout), conf.OneShotCancelOnEscape),
+		repeater,
 	}
 
-	firstEventHandler = h[0]
 	for i, handler := range h {
 		handler.SetLayerManager(executor)
 		if i < len(h)-1 {
@@ -198,6 +269,44 @@ func initHandlers(conf *config.Config) {
 			handler.SetNextHandler(executor)
 		}
 	}
+	return &handlerChain{profile: profile, executor: executor, handlers: h, repeater: repeater}
+}
+
+// name returns the name of the profile of the chain, which is empty for the default chain.
+func (c *handlerChain) name() string {
+	if c.profile == nil {
+		return ""
+	}
+	return c.profile.Name
+}
+
+// chainForDevice returns the handler chain of the first profile that matches the given device, or the default chain.
+func chainForDevice(device *keyboard.Device) *handlerChain {
+	if device == nil {
+		return handlerChains[0]
+	}
+	if chain, ok := deviceChains[device]; ok {
+		return chain
+	}
+	chain := matchingChain(handlerChains, device.Path(), device.Name())
+	if chain.profile != nil {
+		log.Infof("Using profile %s for device %s", chain.profile.Name, device)
+	}
+	deviceChains[device] = chain
+	return chain
+}
+
+// matchingChain returns the chain of the first profile that matches the device with the given path and name, or the
+// shared chain (the first one) if no profile matches.
+func matchingChain(chains []*handlerChain, path string, name string) *handlerChain {
+	for _, c := range chains[1:] {
+		if slices.ContainsFunc(c.profile.Devices, func(deviceConfig string) bool {
+			return deviceMatches(path, name, deviceConfig)
+		}) {
+			return c
+		}
+	}
+	return chains[0]
 }
 
 // mainLoop processes incoming keyboard events and reload config events.
@@ -206,10 +315,53 @@ func mainLoop() {
 		select {
 		case <-reloadConfigChannel:
 			reloadConfig()
+		case device := <-removedDeviceChannel:
+			dropDevice(device)
 		case e := <-keyEventChannel:
-			firstEventHandler.HandleEvent(handlers.EventBinding{Event: e})
+			if e.Device != nil && !e.Device.IsOpen() {
+				// the keys of removed or released devices are released by dropDevice
+				continue
+			}
+			if e.IsRepeat {
+				// repeat events do not change the state of the keys, so the other handlers do not need them
+				chainForDevice(e.Device).repeater.HandleRepeatEvent(handlers.EventBinding{Event: e})
+			} else {
+				trackPressedKey(e)
+				chainForDevice(e.Device).handlers[0].HandleEvent(handlers.EventBinding{Event: e})
+			}
+		}
+	}
+}
+
+// trackPressedKey updates the keys that are held on the device of the given event.
+func trackPressedKey(e keyboard.Event) {
+	if e.Device == nil {
+		return
+	}
+	pressed := slices.DeleteFunc(devicePressedKeys[e.Device], func(code uint16) bool { return code == e.Code })
+	if e.IsPress {
+		pressed = append(pressed, e.Code)
+	}
+	if len(pressed) > 0 {
+		devicePressedKeys[e.Device] = pressed
+	} else {
+		delete(devicePressedKeys, e.Device)
+	}
+}
+
+// dropDevice releases all keys that are still held on the given device, which has been removed or released, so that
+// the handlers do not keep its state, and forgets about the device.
+func dropDevice(device *keyboard.Device) {
+	if pressed := devicePressedKeys[device]; len(pressed) > 0 {
+		chain := chainForDevice(device)
+		for _, code := range pressed {
+			log.Debugf("Releasing the key %d of the dropped device %s", code, device)
+			event := keyboard.Event{Code: code, IsPress: false, Time: time.Now(), Device: device}
+			chain.handlers[0].HandleEvent(handlers.EventBinding{Event: event})
 		}
 	}
+	delete(devicePressedKeys, device)
+	delete(deviceChains, device)
 }
 
 // watchForKeyboardDevices starts a watcher for devices in /dev/input, and adds or removes keyboard
@@ -258,14 +410,113 @@ func watchForKeyboardDevices() error {
 	return nil
 }
 
+// watchConfigFiles starts a watcher for the given config files, which reloads the config when one of them changes.
+// Instead of the files themselves their directories are watched, since many editors save a file by writing a new one
+// and renaming it, which would end a watch on the file.
+func watchConfigFiles(files []string) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	setConfigFiles(watcher, files)
+
+	go func() {
+		defer watcher.Close()
+
+		var reloadTimer *time.Timer
+		for {
+			select {
+			case e, ok := <-watcher.Events:
+				if !ok {
+					log.Errorf("Config watcher closed unexpectedly")
+					return
+				}
+				isChange := e.Op.Has(fsnotify.Write) || e.Op.Has(fsnotify.Create) ||
+					e.Op.Has(fsnotify.Rename) || e.Op.Has(fsnotify.Remove)
+				if !isChange || !isConfigFile(e.Name) {
+					continue
+				}
+				log.Debugf("Detected a change of the config: %s", e)
+				// wait until the file does not change anymore
+				if reloadTimer != nil {
+					reloadTimer.Stop()
+				}
+				reloadTimer = time.AfterFunc(configReloadDelay, func() {
+					select {
+					case reloadConfigChannel <- struct{}{}:
+					default:
+					}
+				})
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					log.Errorf("Config watcher closed unexpectedly")
+					return
+				}
+				log.Warnf("Config watcher error: %v", err)
+			}
+		}
+	}()
+	configWatcher = watcher
+	return nil
+}
+
+// setConfigFiles sets the config files to watch and adds their directories to the given watcher.
+// For files that are symlinks, the targets are watched as well, so that e.g. changes in a dotfiles repository are
+// detected.
+func setConfigFiles(watcher *fsnotify.Watcher, files []string) {
+	configFilesMutex.Lock()
+	defer configFilesMutex.Unlock()
+	configFiles = slices.Clone(files)
+	for _, file := range files {
+		if target, err := filepath.EvalSymlinks(file); err == nil && target != file {
+			configFiles = append(configFiles, target)
+		}
+	}
+	for _, file := range configFiles {
+		dir := filepath.Dir(file)
+		if slices.Contains(watcher.WatchList(), dir) {
+			continue
+		}
+		log.Debugf("Watching for config changes in: %s", dir)
+		if err := watcher.Add(dir); err != nil {
+			log.Warnf("Failed to watch the directory %s: %v", dir, err)
+		}
+	}
+}
+
+// isConfigFile checks if the given file is the config file or one of its includes.
+func isConfigFile(file string) bool {
+	configFilesMutex.Lock()
+	defer configFilesMutex.Unlock()
+	return slices.Contains(configFiles, filepath.Clean(file))
+}
+
+// reloadOnSignal reloads the config when a SIGHUP is received.
+func reloadOnSignal() {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP)
+	go func() {
+		for range signals {
+			log.Debugf("Received SIGHUP")
+			select {
+			case reloadConfigChannel <- struct{}{}:
+			default:
+			}
+		}
+	}()
+}
+
 // deviceCreated is called when a new device file is created.
 func deviceCreated(e fsnotify.Event) {
+	devicesMutex.Lock()
 	for _, dev := range keyboardDevices {
 		if dev.Path() == e.Name {
 			log.Infof("Device already conntected: %s", dev.Path())
+			devicesMutex.Unlock()
 			return
 		}
 	}
+	devicesMutex.Unlock()
 	var device *evdev.InputDevice
 	var err error
 	// wait for udev to fix permissions, otherwise one can get permission denied on open
@@ -280,8 +531,12 @@ func deviceCreated(e fsnotify.Event) {
 		log.Warnf("Failed to open device %s: %v", e.Name, err)
 		return
 	}
-	if !shallDeviceBeUsed(device) {
+	devicesMutex.Lock()
+	use := shallDeviceBeUsed(device, configDevices, configDevicesExclude)
+	devicesMutex.Unlock()
+	if !use {
 		log.Debugf("Ignoring the device")
+		_ = device.File.Close()
 	} else {
 		log.Infof("Detected new keyboard device: %s (%s)", device.Fn, device.Name)
 		addDevice(device)
@@ -290,6 +545,8 @@ func deviceCreated(e fsnotify.Event) {
 
 // deviceRemoved is called when a device file is removed.
 func deviceRemoved(e fsnotify.Event) {
+	devicesMutex.Lock()
+	var removed *keyboard.Device
 	for i, dev := range keyboardDevices {
 		if dev.Path() == e.Name {
 			log.Infof("Keybord device has been removed: %s", dev)
@@ -298,9 +555,15 @@ func deviceRemoved(e fsnotify.Event) {
 			if len(keyboardDevices) == 0 {
 				log.Warnf("No more keyboard devices connected to read from")
 			}
+			removed = dev
 			break
 		}
 	}
+	devicesMutex.Unlock()
+	// sent without holding the lock, since the main loop might wait for it
+	if removed != nil {
+		removedDeviceChannel <- removed
+	}
 }
 
 // addDevice adds the given keyboard device to the list of keyboard devices to read from.
@@ -312,21 +575,174 @@ func addDevice(device *evdev.InputDevice) {
 		log.Warnf("Failed to grab keyboard device %s: %v", device.Fn, err)
 		return
 	}
+	devicesMutex.Lock()
 	keyboardDevices = append(keyboardDevices, kd)
+	devicesMutex.Unlock()
 }
 
-// reloadConfig reloads the config file and updates the handlers, but does not
-// update the keyboard devices specification.
+// updateDevices applies the device specification of the given config, i.e. keyboard devices that do not match anymore
+// are released and newly matching ones are added. Devices that still match are not touched.
+func updateDevices(conf *config.Config) {
+	allDevices, err := evdev.ListInputDevices("/dev/input/event*")
+	if err != nil {
+		log.Warnf("Failed to list the input devices: %v", err)
+		return
+	}
+
+	devicesMutex.Lock()
+	configDevices = conf.Devices
+	configDevicesExclude = conf.DevicesExclude
+	usedPaths := make([]string, len(keyboardDevices))
+	for i, device := range keyboardDevices {
+		usedPaths[i] = device.Path()
+	}
+	newDevices, releasedPaths := diffDevices(usedPaths, allDevices, configDevices, configDevicesExclude)
+	var releasedDevices []*keyboard.Device
+	for _, path := range releasedPaths {
+		i := slices.IndexFunc(keyboardDevices, func(d *keyboard.Device) bool { return d.Path() == path })
+		log.Infof("Releasing keyboard device, since it does not match anymore: %s", keyboardDevices[i])
+		keyboardDevices[i].Release()
+		releasedDevices = append(releasedDevices, keyboardDevices[i])
+		keyboardDevices = slices.Delete(keyboardDevices, i, i+1)
+	}
+	for _, device := range allDevices {
+		if slices.Contains(newDevices, device) {
+			log.Infof("Found keyboard device: %s (%s)", device.Fn, device.Name)
+		} else {
+			_ = device.File.Close()
+		}
+	}
+	devicesMutex.Unlock()
+
+	for _, device := range releasedDevices {
+		dropDevice(device)
+	}
+	for _, device := range newDevices {
+		addDevice(device)
+	}
+	devicesMutex.Lock()
+	if len(keyboardDevices) == 0 {
+		log.Warnf("No keyboard devices found")
+	}
+	devicesMutex.Unlock()
+}
+
+// diffDevices compares the used keyboard devices, given by their paths, with the available devices for the given device
+// specification. It returns the available devices that shall be used but are not yet, and the paths of the used devices
+// that shall not be used anymore. Used devices that are not available anymore are left to the device watcher.
+func diffDevices(
+	usedPaths []string,
+	available []*evdev.InputDevice,
+	devices []string,
+	devicesExclude []string,
+) (added []*evdev.InputDevice, released []string) {
+	for _, device := range available {
+		isUsed := slices.Contains(usedPaths, device.Fn)
+		use := shallDeviceBeUsed(device, devices, devicesExclude)
+		if isUsed && !use {
+			released = append(released, device.Fn)
+		}
+		if !isUsed && use {
+			added = append(added, device)
+		}
+	}
+	return added, released
+}
+
+// replaceHandlers replaces the handlers with ones for the given config, where the runtime state is kept, i.e. the
+// active layers and the events that are still queued in the old handlers. The state of a profile is taken over by the
+// profile with the same name, the state of removed profiles is dropped.
+func replaceHandlers(conf *config.Config) {
+	oldChains := handlerChains
+	// stop the old handlers first, so that they do not forward any events anymore
+	queuedEvents := make([][][]handlers.EventBinding, len(oldChains))
+	for c, chain := range oldChains {
+		queuedEvents[c] = make([][]handlers.EventBinding, len(chain.handlers))
+		for i, handler := range chain.handlers {
+			if queue, ok := handler.(handlers.EventQueue); ok {
+				queuedEvents[c][i] = queue.TakeQueuedEvents()
+			}
+		}
+	}
+
+	initHandlers(conf)
+
+	for c, oldChain := range oldChains {
+		// the events of removed profiles are passed to the default chain
+		chain := handlerChains[0]
+		if i := slices.IndexFunc(handlerChains, func(n *handlerChain) bool { return n.name() == oldChain.name() }); i >= 0 {
+			chain = handlerChains[i]
+			chain.executor.TakeOverState(oldChain.executor)
+		}
+		// the events queued in later handlers are older, since they have already passed the handlers before
+		for i := len(queuedEvents[c]) - 1; i >= 0; i-- {
+			for _, event := range queuedEvents[c][i] {
+				log.Debugf("Passing queued event to the new handlers: %+v", event)
+				chain.handlers[i].HandleEvent(event)
+			}
+		}
+	}
+}
+
+// reloadConfig reloads the config file and updates the handlers and the used keyboard devices.
 func reloadConfig() {
 	log.Infof("Reloading the config file: %s", configFile)
 	var err error
 	conf, err := config.ReadConfig(configFile)
 	if err != nil {
-		log.Warnf("Failed to read the config file: %v", err)
+		log.Errorf("Failed to reload the config file, keeping the current config: %v", err)
 		return
 	}
-	initHandlers(conf)
+	// the mouse parameters of the active layer are set when the handlers are replaced
 	virtualMouse.SetConfig(conf)
+	replaceHandlers(conf)
+	updateDevices(conf)
+	// the includes might have changed
+	if configWatcher != nil {
+		setConfigFiles(configWatcher, conf.Files)
+	}
+}
+
+// checkConfig validates the config file, prints all problems and exits, with a non-zero exit code if there are any.
+func checkConfig() {
+	conf, err := config.ReadConfig(configFile)
+	if err != nil {
+		// the error already contains the file and position
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	problems := config.Check(conf)
+	if len(problems) == 0 {
+		fmt.Printf("%s: ok\n", configFile)
+		os.Exit(0)
+	}
+	for _, problem := range problems {
+		fmt.Println(problem)
+	}
+	fmt.Printf("%d problem(s) found\n", len(problems))
+	os.Exit(1)
+}
+
+// printKeys prints the names of all keys, which can be used in the config, together with their codes.
+func printKeys() {
+	namesByCode := make(map[uint16][]string)
+	for alias, code := range config.AllKeyAliases() {
+		namesByCode[code] = append(namesByCode[code], alias)
+	}
+	codes := make([]uint16, 0, len(namesByCode))
+	for code := range namesByCode {
+		codes = append(codes, code)
+	}
+	slices.Sort(codes)
+	headers := []string{"Code", "Name", "Other names"}
+	rows := [][]string{}
+	for _, code := range codes {
+		primary, _ := config.GetKeyAlias(code)
+		others := slices.DeleteFunc(namesByCode[code], func(name string) bool { return name == primary })
+		sort.Strings(others)
+		rows = append(rows, []string{fmt.Sprintf("%d", code), primary, strings.Join(others, ", ")})
+	}
+	printTable(headers, rows)
 }
 
 // printDevices prints all input devices with their capabilities.
@@ -399,17 +815,21 @@ func printTable(headers []string, rows [][]string) {
 
 // shallDeviceBeUsed checks if the given device should be used.
 // This is the case if these two conditions are met:
-// 1. (config.devices is empty and device is a keyboard) or (device is listed in config.devices)
-// 2. device is not listed in config.devicesExclude
-func shallDeviceBeUsed(device *evdev.InputDevice) bool {
-	if len(configDevices) == 0 {
+// 1. (devices is empty and device is a keyboard) or (device is listed in devices)
+// 2. device is not listed in devicesExclude
+func shallDeviceBeUsed(device *evdev.InputDevice, devices []string, devicesExclude []string) bool {
+	// never read from the own virtual devices
+	if slices.Contains(virtualDeviceNames, device.Name) {
+		return false
+	}
+	if len(devices) == 0 {
 		if !isKeyboardDevice(device) {
 			return false
 		}
 	} else {
 		anyMatches := false
-		for _, deviceConfig := range configDevices {
-			if deviceMatches(device, deviceConfig) {
+		for _, deviceConfig := range devices {
+			if deviceMatches(device.Fn, device.Name, deviceConfig) {
 				anyMatches = true
 				break
 			}
@@ -418,24 +838,24 @@ func shallDeviceBeUsed(device *evdev.InputDevice) bool {
 			return false
 		}
 	}
-	for _, deviceConfig := range configDevicesExclude {
-		if deviceMatches(device, deviceConfig) {
+	for _, deviceConfig := range devicesExclude {
+		if deviceMatches(device.Fn, device.Name, deviceConfig) {
 			return false
 		}
 	}
 	return true
 }
 
-// deviceMatches checks if the given device matches the given deviceConfig by checking if
+// deviceMatches checks if the device with the given path and name matches the given deviceConfig by checking if
 // it matches either the device name or the device path.
-func deviceMatches(device *evdev.InputDevice, deviceConfig string) bool {
-	if deviceConfig == device.Fn || deviceConfig == device.Name {
+func deviceMatches(path string, name string, deviceConfig string) bool {
+	if deviceConfig == path || deviceConfig == name {
 		return true
 	}
 	// if it is a symlink, resolve it and check if the resolved path matches
 	dest, err := filepath.EvalSymlinks(deviceConfig)
 	if err == nil {
-		if dest == device.Fn {
+		if dest == path {
 			return true
 		}
 	}
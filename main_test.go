@@ -0,0 +1,87 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/jbensmann/mouseless/config"
+
+	evdev "github.com/gvalkov/golang-evdev"
+)
+
+func TestMatchingChain(t *testing.T) {
+	shared := &handlerChain{}
+	split := &handlerChain{profile: &config.Profile{Name: "split", Devices: []string{"Moonlander", "/dev/input/event3"}}}
+	laptop := &handlerChain{profile: &config.Profile{Name: "laptop", Devices: []string{"AT Keyboard", "Moonlander"}}}
+	chains := []*handlerChain{shared, split, laptop}
+
+	tests := []struct {
+		path     string
+		name     string
+		expected *handlerChain
+	}{
+		{"/dev/input/event5", "Moonlander", split},
+		{"/dev/input/event3", "Other", split},
+		{"/dev/input/event4", "AT Keyboard", laptop},
+		// devices that match no profile share the first chain
+		{"/dev/input/event6", "Other", shared},
+	}
+	for _, test := range tests {
+		if chain := matchingChain(chains, test.path, test.name); chain != test.expected {
+			t.Errorf("expected the chain %+v but got %+v for the device %s (%s)", test.expected.profile,
+				chain.profile, test.name, test.path)
+		}
+	}
+	// the first matching profile wins if a device matches two of them
+	if chain := matchingChain([]*handlerChain{shared, laptop, split}, "", "Moonlander"); chain != laptop {
+		t.Errorf("expected the first matching profile but got %+v", chain.profile)
+	}
+	if chain := matchingChain([]*handlerChain{shared}, "", "Moonlander"); chain != shared {
+		t.Errorf("expected the shared chain without profiles but got %+v", chain.profile)
+	}
+}
+
+func TestDiffDevices(t *testing.T) {
+	keyboardCapabilities := map[evdev.CapabilityType][]evdev.CapabilityCode{
+		{Type: evdev.EV_KEY}: {{Code: evdev.KEY_A}},
+	}
+	available := []*evdev.InputDevice{
+		{Fn: "/dev/input/event1", Name: "Keyboard A", Capabilities: keyboardCapabilities},
+		{Fn: "/dev/input/event2", Name: "Keyboard B", Capabilities: keyboardCapabilities},
+		{Fn: "/dev/input/event3", Name: "Mouse"},
+	}
+
+	tests := []struct {
+		usedPaths      []string
+		devices        []string
+		devicesExclude []string
+		added          string
+		released       string
+	}{
+		// all keyboards are grabbed without a device specification
+		{nil, nil, nil, "/dev/input/event1 /dev/input/event2", ""},
+		{[]string{"/dev/input/event1", "/dev/input/event2"}, nil, nil, "", ""},
+		// an excluded device is released, by name or by path
+		{[]string{"/dev/input/event1", "/dev/input/event2"}, nil, []string{"Keyboard B"}, "", "/dev/input/event2"},
+		{[]string{"/dev/input/event1"}, nil, []string{"/dev/input/event1"}, "/dev/input/event2", "/dev/input/event1"},
+		// listed devices are used even if they are no keyboards, all others are released
+		{[]string{"/dev/input/event1", "/dev/input/event2"}, []string{"Mouse", "Keyboard A"}, nil,
+			"/dev/input/event3", "/dev/input/event2"},
+		{[]string{"/dev/input/event3"}, []string{"Mouse"}, []string{"Mouse"}, "", "/dev/input/event3"},
+		// devices that are not available anymore are left to the device watcher
+		{[]string{"/dev/input/event9"}, []string{"Keyboard A"}, nil, "/dev/input/event1", ""},
+	}
+	for _, test := range tests {
+		added, released := diffDevices(test.usedPaths, available, test.devices, test.devicesExclude)
+		var addedPaths []string
+		for _, device := range added {
+			addedPaths = append(addedPaths, device.Fn)
+		}
+		if paths := strings.Join(addedPaths, " "); paths != test.added {
+			t.Errorf("expected the devices '%s' to be added but got '%s' for %+v", test.added, paths, test)
+		}
+		if paths := strings.Join(released, " "); paths != test.released {
+			t.Errorf("expected the devices '%s' to be released but got '%s' for %+v", test.released, paths, test)
+		}
+	}
+}
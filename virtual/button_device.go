@@ -0,0 +1,143 @@
+package virtual
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"syscall"
+	"time"
+)
+
+// the definitions from uinput.h and input-event-codes.h that are needed for the button device
+const (
+	uiDevCreate  = 0x5501
+	uiDevDestroy = 0x5502
+	uiSetEvBit   = 0x40045564
+	uiSetKeyBit  = 0x40045565
+	uiSetRelBit  = 0x40045566
+
+	evSyn     = 0x00
+	evKey     = 0x01
+	evRel     = 0x02
+	synReport = 0x00
+	relX      = 0x00
+	relY      = 0x01
+
+	busUsb            = 0x03
+	uinputMaxNameSize = 80
+	absSize           = 64
+)
+
+// uinputUserDev is the uinput_user_dev struct of uinput.h.
+type uinputUserDev struct {
+	Name       [uinputMaxNameSize]byte
+	ID         struct{ Bustype, Vendor, Product, Version uint16 }
+	EffectsMax uint32
+	Absmax     [absSize]int32
+	Absmin     [absSize]int32
+	Absfuzz    [absSize]int32
+	Absflat    [absSize]int32
+}
+
+// inputEvent is the input_event struct of input.h.
+type inputEvent struct {
+	Time  syscall.Timeval
+	Type  uint16
+	Code  uint16
+	Value int32
+}
+
+// buttonDevice is a virtual pointer device for the mouse buttons that the uinput package does not support, i.e. all
+// buttons except left, right and middle. It only presses buttons, the pointer is moved by the uinput mouse.
+type buttonDevice struct {
+	deviceFile *os.File
+	writer     io.Writer
+}
+
+// createButtonDevice creates a device that can press the buttons with the given codes. It also registers the x and y
+// axes, without which it would not be recognized as a pointer device.
+func createButtonDevice(path string, name string, buttons []uint16) (*buttonDevice, error) {
+	if len(name) == 0 || len(name) > uinputMaxNameSize {
+		return nil, fmt.Errorf("the device name must have 1 to %d characters", uinputMaxNameSize)
+	}
+	deviceFile, err := os.OpenFile(path, syscall.O_WRONLY|syscall.O_NONBLOCK, 0660)
+	if err != nil {
+		return nil, fmt.Errorf("could not open the device file: %v", err)
+	}
+	d := &buttonDevice{deviceFile: deviceFile, writer: deviceFile}
+
+	bits := []struct {
+		request uintptr
+		values  []uint16
+	}{
+		{uiSetEvBit, []uint16{evKey, evRel}},
+		{uiSetKeyBit, buttons},
+		{uiSetRelBit, []uint16{relX, relY}},
+	}
+	for _, b := range bits {
+		for _, value := range b.values {
+			if err := d.ioctl(b.request, uintptr(value)); err != nil {
+				_ = deviceFile.Close()
+				return nil, fmt.Errorf("failed to register the event %v: %v", value, err)
+			}
+		}
+	}
+
+	dev := uinputUserDev{}
+	dev.ID.Bustype = busUsb
+	dev.ID.Vendor = 0x4711
+	dev.ID.Product = 0x0817
+	dev.ID.Version = 1
+	copy(dev.Name[:], name)
+	buf := new(bytes.Buffer)
+	if err := binary.Write(buf, binary.LittleEndian, dev); err == nil {
+		_, err = deviceFile.Write(buf.Bytes())
+	}
+	if err == nil {
+		err = d.ioctl(uiDevCreate, 0)
+	}
+	if err != nil {
+		_ = deviceFile.Close()
+		return nil, fmt.Errorf("failed to create the device: %v", err)
+	}
+	// give the system some time to pick up the new device
+	time.Sleep(200 * time.Millisecond)
+	return d, nil
+}
+
+// button presses or releases the button with the given code.
+func (d *buttonDevice) button(code uint16, isPress bool) error {
+	var value int32
+	if isPress {
+		value = 1
+	}
+	buf := new(bytes.Buffer)
+	for _, event := range []inputEvent{{Type: evKey, Code: code, Value: value}, {Type: evSyn, Code: synReport}} {
+		if err := binary.Write(buf, binary.LittleEndian, event); err != nil {
+			return fmt.Errorf("failed to write the event: %v", err)
+		}
+	}
+	if _, err := d.writer.Write(buf.Bytes()); err != nil {
+		return fmt.Errorf("failed to write the events to the device file: %v", err)
+	}
+	return nil
+}
+
+// close destroys the device.
+func (d *buttonDevice) close() error {
+	err := d.ioctl(uiDevDestroy, 0)
+	if closeErr := d.deviceFile.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+func (d *buttonDevice) ioctl(request, arg uintptr) error {
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, d.deviceFile.Fd(), request, arg)
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
@@ -0,0 +1,37 @@
+package virtual
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+func TestButtonDeviceEvents(t *testing.T) {
+	var buf bytes.Buffer
+	d := &buttonDevice{writer: &buf}
+	if err := d.button(0x113, true); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.button(0x113, false); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []inputEvent{
+		{Type: evKey, Code: 0x113, Value: 1},
+		{Type: evSyn, Code: synReport},
+		{Type: evKey, Code: 0x113, Value: 0},
+		{Type: evSyn, Code: synReport},
+	}
+	for i, e := range expected {
+		var event inputEvent
+		if err := binary.Read(&buf, binary.LittleEndian, &event); err != nil {
+			t.Fatalf("expected %d events but could only read %d: %v", len(expected), i, err)
+		}
+		if event != e {
+			t.Errorf("expected the event %+v but got %+v", e, event)
+		}
+	}
+	if buf.Len() != 0 {
+		t.Errorf("expected no more events but got %d bytes", buf.Len())
+	}
+}
@@ -1,16 +1,22 @@
 package virtual
 
 import (
+	"sync"
+
 	"github.com/jbensmann/mouseless/config"
 	"github.com/jbensmann/uinput"
 	log "github.com/sirupsen/logrus"
 )
 
 type Keyboard struct {
+	// guards the fields below, since macros press keys from their own goroutine
+	mutex            sync.Mutex
 	uinputKeyboard   uinput.Keyboard
 	isPressed        map[uint16]bool
 	pressedModifiers map[uint16]bool
 	triggeredKeys    map[uint16][]uint16
+	// the keys pressed via HoldKeys, which are left alone by PressKeys and OriginalKeyUp
+	heldKeys map[uint16]bool
 }
 
 func NewKeyboard(devicesName string) (*Keyboard, error) {
@@ -19,6 +25,7 @@ func NewKeyboard(devicesName string) (*Keyboard, error) {
 		isPressed:        make(map[uint16]bool),
 		pressedModifiers: make(map[uint16]bool),
 		triggeredKeys:    make(map[uint16][]uint16),
+		heldKeys:         make(map[uint16]bool),
 	}
 	v.uinputKeyboard, err = uinput.CreateKeyboard("/dev/uinput", []byte(devicesName))
 	if err != nil {
@@ -29,12 +36,17 @@ func NewKeyboard(devicesName string) (*Keyboard, error) {
 
 // PressKeys presses the given keys and releases them automatically when the given trigger key is released.
 func (v *Keyboard) PressKeys(triggeredByKey uint16, codes []uint16) {
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
 	v.triggeredKeys[triggeredByKey] = append(v.triggeredKeys[triggeredByKey], codes...)
 	// release previous modifiers
 	for c := range v.pressedModifiers {
 		v.releaseKey(c)
 	}
 	for i, c := range codes {
+		if v.heldKeys[c] {
+			continue
+		}
 		v.pressKey(c)
 		if i < len(codes)-1 {
 			v.pressedModifiers[c] = true
@@ -44,14 +56,44 @@ func (v *Keyboard) PressKeys(triggeredByKey uint16, codes []uint16) {
 
 // PressKeyManually can be used to press a key without automatic release, which must be done by calling ReleaseKeyManually.
 func (v *Keyboard) PressKeyManually(code uint16) {
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
 	v.pressKey(code)
 }
 
 // ReleaseKeyManually must be called eventually to release a key that was pressed via PressKeyManually.
 func (v *Keyboard) ReleaseKeyManually(code uint16) {
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
 	v.releaseKey(code)
 }
 
+// HoldKeys presses the given keys until they are released with ReleaseHeldKeys, e.g. for one-shot modifiers. Other
+// key presses do not release them, unlike the modifiers pressed by PressKeys.
+func (v *Keyboard) HoldKeys(codes []uint16) {
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+	for _, c := range codes {
+		if !v.heldKeys[c] {
+			v.pressKey(c)
+			v.heldKeys[c] = true
+			delete(v.pressedModifiers, c)
+		}
+	}
+}
+
+// ReleaseHeldKeys releases keys that have been pressed via HoldKeys.
+func (v *Keyboard) ReleaseHeldKeys(codes []uint16) {
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+	for i := len(codes) - 1; i >= 0; i-- {
+		if v.heldKeys[codes[i]] {
+			v.releaseKey(codes[i])
+			delete(v.heldKeys, codes[i])
+		}
+	}
+}
+
 func (v *Keyboard) pressKey(code uint16) {
 	alias, _ := config.GetKeyAlias(code)
 	log.Debugf("Keyboard: pressing %v (%v)", alias, code)
@@ -74,9 +116,11 @@ func (v *Keyboard) releaseKey(code uint16) {
 }
 
 func (v *Keyboard) OriginalKeyUp(code uint16) {
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
 	if codes, ok := v.triggeredKeys[code]; ok {
 		for _, c := range codes {
-			if pressed, ok := v.isPressed[c]; ok && pressed {
+			if pressed, ok := v.isPressed[c]; ok && pressed && !v.heldKeys[c] {
 				v.releaseKey(c)
 			}
 		}
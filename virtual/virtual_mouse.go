@@ -21,17 +21,24 @@ func (d *Vector) Add(d2 Vector) {
 	d.y += d2.y
 }
 
+// the functions of the uinput mouse that press and release a button, the other buttons are pressed with a buttonDevice
+var uinputButtons = map[config.MouseButton][2]func(uinput.Mouse) error{
+	config.ButtonLeft:   {uinput.Mouse.LeftPress, uinput.Mouse.LeftRelease},
+	config.ButtonRight:  {uinput.Mouse.RightPress, uinput.Mouse.RightRelease},
+	config.ButtonMiddle: {uinput.Mouse.MiddlePress, uinput.Mouse.MiddleRelease},
+}
+
 type Mouse struct {
-	uinputMouse uinput.Mouse
+	uinputMouse  uinput.Mouse
+	buttonDevice *buttonDevice
 
-	mouseLoopInterval      time.Duration
-	baseMouseSpeed         float64
-	baseScrollSpeed        float64
-	startMouseSpeed        float64
-	mouseAccelerationTime  float64
-	mouseDecelerationTime  float64
-	mouseAccelerationCurve float64
-	mouseDecelerationCurve float64
+	mouseLoopInterval time.Duration
+	// the parameters of the first layer, used for sources without parameters
+	defaultParams config.MouseParameters
+	// the parameters of each source, e.g. the executor of a profile
+	paramsBySource map[any]config.MouseParameters
+	// the source that has changed the movement last, whose parameters are used
+	activeSource any
 
 	isButtonPressed map[config.MouseButton]bool
 
@@ -51,10 +58,13 @@ type Mouse struct {
 	mouseMoveEventsChannel chan struct{}
 }
 
-func NewMouse(conf *config.Config, deviceName string) (*Mouse, error) {
+// NewMouse creates the virtual mouse, where the buttons that the uinput mouse does not support are pressed by a
+// second device with the name buttonDeviceName.
+func NewMouse(conf *config.Config, deviceName string, buttonDeviceName string) (*Mouse, error) {
 	var err error
 	v := Mouse{
 		isButtonPressed:        make(map[config.MouseButton]bool),
+		paramsBySource:         make(map[any]config.MouseParameters),
 		buttonsByKeys:          make(map[uint16]config.MouseButton),
 		moveByKeys:             make(map[uint16]Vector),
 		scrollByKeys:           make(map[uint16]Vector),
@@ -70,19 +80,66 @@ func NewMouse(conf *config.Config, deviceName string) (*Mouse, error) {
 	if err != nil {
 		return nil, err
 	}
+	var extraButtons []uint16
+	for _, button := range config.MouseButtons() {
+		if _, ok := uinputButtons[button]; !ok {
+			extraButtons = append(extraButtons, button.Code())
+		}
+	}
+	v.buttonDevice, err = createButtonDevice("/dev/uinput", buttonDeviceName, extraButtons)
+	if err != nil {
+		_ = v.uinputMouse.Close()
+		return nil, err
+	}
 	return &v, nil
 }
 
-// SetConfig updates the relevant parameters from the config file.
+// SetConfig updates the relevant parameters from the config file, where the mouse parameters of the first layer are
+// used until SetParameters is called. The parameters of all sources are dropped.
 func (m *Mouse) SetConfig(conf *config.Config) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
 	m.mouseLoopInterval = time.Duration(conf.MouseLoopInterval) * time.Millisecond
-	m.baseMouseSpeed = conf.BaseMouseSpeed
-	m.baseScrollSpeed = conf.BaseScrollSpeed
-	m.startMouseSpeed = conf.StartMouseSpeed
-	m.mouseAccelerationTime = conf.MouseAccelerationTime
-	m.mouseDecelerationTime = conf.MouseDecelerationTime
-	m.mouseAccelerationCurve = conf.MouseAccelerationCurve
-	m.mouseDecelerationCurve = conf.MouseDecelerationCurve
+	m.defaultParams = conf.Layers[0].MouseParameters
+	clear(m.paramsBySource)
+}
+
+// SetParameters changes the parameters for mouse movement and scrolling of the given source, e.g. when the layer of
+// an executor changes. The parameters of the source that has changed the movement last are used, so that sources do
+// not override each other. The current velocity is kept, so that the movement changes smoothly towards the new speed.
+func (m *Mouse) SetParameters(source any, params config.MouseParameters) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if previous, ok := m.paramsBySource[source]; !ok || params != previous {
+		log.Debugf("Mouse: changing parameters to %+v", params)
+		m.paramsBySource[source] = params
+	}
+}
+
+// ReplaceSource lets the given new source take over the movement of the old one, e.g. when the config is reloaded.
+func (m *Mouse) ReplaceSource(oldSource any, newSource any) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if params, ok := m.paramsBySource[oldSource]; ok {
+		delete(m.paramsBySource, oldSource)
+		if _, ok := m.paramsBySource[newSource]; !ok {
+			m.paramsBySource[newSource] = params
+		}
+	}
+	if m.activeSource == oldSource {
+		m.activeSource = newSource
+	}
+}
+
+// params returns the parameters of the active source.
+func (m *Mouse) params() config.MouseParameters {
+	if params, ok := m.paramsBySource[m.activeSource]; ok {
+		return params
+	}
+	return m.defaultParams
 }
 
 func (m *Mouse) StartLoop() {
@@ -94,44 +151,37 @@ func (m *Mouse) ButtonPress(triggeredByKey uint16, button config.MouseButton) {
 	m.lock.Lock()
 	defer m.lock.Unlock()
 
-	var err error
 	m.buttonsByKeys[triggeredByKey] = button
 	m.isButtonPressed[button] = true
 	log.Debugf("Mouse: pressing %v", button)
-	if button == config.ButtonLeft {
-		err = m.uinputMouse.LeftPress()
-	} else if button == config.ButtonMiddle {
-		err = m.uinputMouse.MiddlePress()
-	} else if button == config.ButtonRight {
-		err = m.uinputMouse.RightPress()
-	} else {
-		log.Warnf("Mouse: unknown button: %v", button)
-	}
-	if err != nil {
+	if err := m.button(button, true); err != nil {
 		log.Warnf("Mouse: button press failed: %v", err)
 	}
 }
 
-func (m *Mouse) ChangeMoveSpeed(triggeredByKey uint16, x float64, y float64) {
+func (m *Mouse) ChangeMoveSpeed(source any, triggeredByKey uint16, x float64, y float64) {
 	m.lock.Lock()
 	defer m.lock.Unlock()
 
+	m.activeSource = source
 	m.moveByKeys[triggeredByKey] = Vector{x, y}
 	m.mouseMoveChange()
 }
 
-func (m *Mouse) ChangeScrollSpeed(triggeredByKey uint16, x float64, y float64) {
+func (m *Mouse) ChangeScrollSpeed(source any, triggeredByKey uint16, x float64, y float64) {
 	m.lock.Lock()
 	defer m.lock.Unlock()
 
+	m.activeSource = source
 	m.scrollByKeys[triggeredByKey] = Vector{x, y}
 	m.mouseMoveChange()
 }
 
-func (m *Mouse) AddSpeedFactor(triggeredByKey uint16, speedFactor float64) {
+func (m *Mouse) AddSpeedFactor(source any, triggeredByKey uint16, speedFactor float64) {
 	m.lock.Lock()
 	defer m.lock.Unlock()
 
+	m.activeSource = source
 	m.speedByKeys[triggeredByKey] = speedFactor
 	m.mouseMoveChange()
 }
@@ -143,27 +193,15 @@ func (m *Mouse) OriginalKeyUp(code uint16) {
 	delete(m.moveByKeys, code)
 	delete(m.scrollByKeys, code)
 	delete(m.speedByKeys, code)
+	m.buttonRelease(code)
+}
 
-	if button, ok := m.buttonsByKeys[code]; ok {
-		if pressed, ok := m.isButtonPressed[button]; ok && pressed {
-			var err error
-			log.Debugf("Mouse: releasing %v", button)
-			if button == config.ButtonLeft {
-				err = m.uinputMouse.LeftRelease()
-			} else if button == config.ButtonMiddle {
-				err = m.uinputMouse.MiddleRelease()
-			} else if button == config.ButtonRight {
-				err = m.uinputMouse.RightRelease()
-			} else {
-				log.Warnf("Mouse: unknown button: %v", button)
-			}
-			if err != nil {
-				log.Warnf("Mouse: button release failed: %v", err)
-			}
-			delete(m.isButtonPressed, button)
-		}
-		delete(m.buttonsByKeys, code)
-	}
+// ButtonRelease releases the button that has been pressed by the given key, while the key is still down.
+func (m *Mouse) ButtonRelease(triggeredByKey uint16) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.buttonRelease(triggeredByKey)
 }
 
 func (m *Mouse) Close() {
@@ -173,6 +211,31 @@ func (m *Mouse) Close() {
 	defer m.lock.Unlock()
 
 	_ = m.uinputMouse.Close()
+	_ = m.buttonDevice.close()
+}
+
+func (m *Mouse) buttonRelease(triggeredByKey uint16) {
+	if button, ok := m.buttonsByKeys[triggeredByKey]; ok {
+		if pressed, ok := m.isButtonPressed[button]; ok && pressed {
+			log.Debugf("Mouse: releasing %v", button)
+			if err := m.button(button, false); err != nil {
+				log.Warnf("Mouse: button release failed: %v", err)
+			}
+			delete(m.isButtonPressed, button)
+		}
+		delete(m.buttonsByKeys, triggeredByKey)
+	}
+}
+
+// button presses or releases the given button with the device that supports it.
+func (m *Mouse) button(button config.MouseButton, isPress bool) error {
+	if functions, ok := uinputButtons[button]; ok {
+		if isPress {
+			return functions[0](m.uinputMouse)
+		}
+		return functions[1](m.uinputMouse)
+	}
+	return m.buttonDevice.button(button.Code(), isPress)
 }
 
 func (m *Mouse) mainLoop() {
@@ -216,18 +279,19 @@ func (m *Mouse) moveAndScroll(updateDuration time.Duration) {
 	}
 
 	if len(m.moveByKeys) > 0 || len(m.scrollByKeys) > 0 || m.isMoving() {
+		params := m.params()
 		tickTime := updateDuration.Seconds()
-		moveSpeed := m.baseMouseSpeed * tickTime
-		scrollSpeed := m.baseScrollSpeed * tickTime
-		accelerationStep := tickTime * 1000 / m.mouseAccelerationTime
-		decelerationStep := tickTime * 1000 / m.mouseDecelerationTime
+		moveSpeed := params.BaseMouseSpeed * tickTime
+		scrollSpeed := params.BaseScrollSpeed * tickTime
+		accelerationStep := tickTime * 1000 / params.MouseAccelerationTime
+		decelerationStep := tickTime * 1000 / params.MouseDecelerationTime
 		m.scroll(scroll.x*scrollSpeed*speedFactor, scroll.y*scrollSpeed*speedFactor)
 		m.move(
-			move.x*moveSpeed, move.y*moveSpeed, m.startMouseSpeed*tickTime,
-			m.baseMouseSpeed*tickTime,
-			m.mouseAccelerationCurve,
+			move.x*moveSpeed, move.y*moveSpeed, params.StartMouseSpeed*tickTime,
+			params.BaseMouseSpeed*tickTime,
+			params.MouseAccelerationCurve,
 			accelerationStep,
-			m.mouseDecelerationCurve,
+			params.MouseDecelerationCurve,
 			decelerationStep,
 			speedFactor,
 		)
@@ -0,0 +1,88 @@
+package virtual
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jbensmann/mouseless/config"
+	"github.com/jbensmann/uinput"
+)
+
+// uinputMouseMock ignores the movements, other methods of uinput.Mouse must not be called.
+type uinputMouseMock struct {
+	uinput.Mouse
+}
+
+func (m uinputMouseMock) Move(_, _ int32) error { return nil }
+
+// newTestMouse returns a mouse that does not create any devices.
+func newTestMouse(conf *config.Config) *Mouse {
+	m := &Mouse{
+		uinputMouse:            uinputMouseMock{},
+		paramsBySource:         make(map[any]config.MouseParameters),
+		isButtonPressed:        make(map[config.MouseButton]bool),
+		buttonsByKeys:          make(map[uint16]config.MouseButton),
+		moveByKeys:             make(map[uint16]Vector),
+		scrollByKeys:           make(map[uint16]Vector),
+		speedByKeys:            make(map[uint16]float64),
+		mouseMoveEventsChannel: make(chan struct{}, 1),
+	}
+	m.SetConfig(conf)
+	return m
+}
+
+func TestMouseParameterSwitch(t *testing.T) {
+	conf, err := config.ParseConfig([]byte(`
+layers:
+- name: fast
+  baseMouseSpeed: 1000
+  mouseAccelerationTime: 100
+  mouseDecelerationTime: 100
+- name: slow
+  baseMouseSpeed: 100
+  mouseAccelerationTime: 100
+  mouseDecelerationTime: 100
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	fast, slow := conf.Layers[0].MouseParameters, conf.Layers[1].MouseParameters
+	m := newTestMouse(conf)
+	tick := 20 * time.Millisecond
+	profile, otherProfile := "profile", "other profile"
+
+	m.SetParameters(profile, fast)
+	m.ChangeMoveSpeed(profile, 30, 1, 0)
+	for i := 0; i < 10; i++ {
+		m.moveAndScroll(tick)
+	}
+	// the speed per tick is the base speed times the tick length
+	if m.velocity.x != 20 {
+		t.Fatalf("expected the velocity 20 of the fast layer but got %v", m.velocity.x)
+	}
+
+	// the parameters of other sources are ignored
+	m.SetParameters(otherProfile, slow)
+	m.moveAndScroll(tick)
+	if m.velocity.x != 20 {
+		t.Errorf("expected the velocity to be unaffected by another source but got %v", m.velocity.x)
+	}
+
+	// the velocity changes smoothly towards the speed of the new layer
+	m.SetParameters(profile, slow)
+	m.moveAndScroll(tick)
+	if m.velocity.x <= 2 || m.velocity.x >= 20 {
+		t.Errorf("expected the velocity to be between 2 and 20 right after the switch but got %v", m.velocity.x)
+	}
+	previous := m.velocity.x
+	for i := 0; i < 100; i++ {
+		m.moveAndScroll(tick)
+		if m.velocity.x > previous {
+			t.Fatalf("expected the velocity to decrease but it went from %v to %v", previous, m.velocity.x)
+		}
+		previous = m.velocity.x
+	}
+	if m.velocity.x != 2 {
+		t.Errorf("expected the velocity 2 of the slow layer but got %v", m.velocity.x)
+	}
+}